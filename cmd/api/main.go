@@ -2,20 +2,36 @@ package main
 
 import (
 	"context"
+	"errors"
 	"expense-tracker/internal/config"
 	"expense-tracker/internal/db"
+	"expense-tracker/internal/graph"
+	"expense-tracker/internal/grpcapi"
 	"expense-tracker/internal/handler"
-	"expense-tracker/internal/middleware"
+	"expense-tracker/internal/health"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/metrics"
+	"expense-tracker/internal/migrate"
+	"expense-tracker/internal/ratelimit"
 	"expense-tracker/internal/repository"
+	"expense-tracker/internal/router"
 	"expense-tracker/internal/services"
+	"expense-tracker/internal/stream"
+	"expense-tracker/internal/tracing"
+	"expense-tracker/internal/utils"
+	"expense-tracker/internal/webhook"
+	"expense-tracker/internal/worker"
+	"expense-tracker/migrations"
+	"flag"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
-
-	"github.com/gin-gonic/gin"
 )
 
 func main() {
@@ -23,14 +39,48 @@ func main() {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
 
-	cfg, err := config.Load()
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
 		slog.Error("failed to load config", "error", err)
 		os.Exit(1)
 	}
-	slog.Info("Config loaded successfully")
 
-	pool, err := db.Connect(cfg.DatabaseURL)
+	logger, err = logging.New(os.Stdout, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		slog.Error("failed to configure logging", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			os.Exit(runMigrate(cfg, args[1:]))
+		case "print-config":
+			cfg.Print(os.Stdout)
+			os.Exit(0)
+		default:
+			fmt.Fprintf(os.Stderr, "unknown command %q (expected migrate or print-config)\n", args[0])
+			os.Exit(2)
+		}
+	}
+	slog.Info("Config loaded successfully", "config", cfg)
+
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		ServiceName: cfg.Tracing.ServiceName,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		slog.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	pool, err := db.Connect(cfg.Database)
 	if err != nil {
 		slog.Error("failed to connect to database", "error", err)
 		os.Exit(1)
@@ -39,56 +89,241 @@ func main() {
 
 	defer pool.Close()
 
+	if err := metrics.RegisterPool("primary", pool.Pool); err != nil {
+		slog.Error("failed to register pool metrics", "error", err)
+		os.Exit(1)
+	}
+
+	// Optional read replica for listings; reads use the primary until it is healthy
+	var replica *db.Pool
+	if cfg.Database.ReplicaURL != "" {
+		replica, err = db.ConnectReplica(cfg.Database)
+		if err != nil {
+			slog.Error("failed to set up read replica", "error", err)
+			os.Exit(1)
+		}
+		defer replica.Close()
+
+		if err := metrics.RegisterPool("replica", replica.Pool); err != nil {
+			slog.Error("failed to register pool metrics", "error", err)
+			os.Exit(1)
+		}
+	}
+	reader := db.NewReadRouter(pool, replica, cfg.Database.ReplicaMaxLag)
+
+	isoLevel, err := repository.ParseIsoLevel(cfg.Database.TxIsolation)
+	if err != nil {
+		slog.Error("invalid database.tx_isolation", "error", err)
+		os.Exit(1)
+	}
+	txManager := repository.NewTxManager(pool, repository.TxOptions{
+		IsoLevel:   isoLevel,
+		MaxRetries: cfg.Database.TxMaxRetries,
+	})
+
+	tokens := utils.NewTokenManager(cfg.JWT)
+
 	// user
 	userRepo := repository.NewUserRepository(pool)
-	userService := services.NewUserService(userRepo)
-	userHandler := handler.NewUserHandler(userService)
+	userService := services.NewUserService(userRepo, txManager)
+	userHandler := handler.NewUserHandler(userService, tokens)
 
 	// Expense
-	expenseRepo := repository.NewExpenseRepository(pool)
-	expenseService := services.NewExpenseService(expenseRepo)
+	expenseRepo := repository.NewExpenseRepository(pool, reader)
+	expenseService := services.NewExpenseService(expenseRepo, txManager)
 	expenseHandler := handler.NewExpenseHandler(expenseService)
 
-	router := gin.Default()
-	router.SetTrustedProxies(nil)
+	// Audit
+	auditRepo := repository.NewAuditRepository(pool, reader)
+	auditService := services.NewAuditService(auditRepo)
+	auditHandler := handler.NewAuditHandler(auditService)
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		slog.Info("health check endpoint called")
-		c.JSON(200, gin.H{
-			"status": "API is working",
-		})
+	// Offline sync
+	syncRepo := repository.NewSyncRepository(pool)
+	syncService := services.NewSyncService(syncRepo, txManager)
+	syncHandler := handler.NewSyncHandler(syncService)
+
+	// Bank statement imports
+	importRepo := repository.NewImportRepository(pool)
+	importService := services.NewImportService(importRepo, txManager)
+	importHandler := handler.NewImportHandler(importService, cfg.Import.MaxFileBytes)
+
+	// Categorization rules
+	ruleRepo := repository.NewRuleRepository(pool)
+	ruleService := services.NewRuleService(ruleRepo, expenseRepo, txManager)
+	ruleHandler := handler.NewRuleHandler(ruleService)
+
+	// Budgets
+	budgetRepo := repository.NewBudgetRepository(pool)
+	budgetService := services.NewBudgetService(budgetRepo)
+	budgetHandler := handler.NewBudgetHandler(budgetService)
+
+	// Webhooks
+	webhookRepo := repository.NewWebhookRepository(pool)
+	outboxRepo := repository.NewOutboxRepository(pool)
+	webhookService := services.NewWebhookService(webhookRepo, outboxRepo, txManager, services.WebhookOptions{
+		AllowInsecureTargets: cfg.Webhooks.AllowInsecureTargets,
+		MaxAttempts:          cfg.Webhooks.MaxAttempts,
+		RetryBase:            cfg.Webhooks.RetryBase,
+		RetryMax:             cfg.Webhooks.RetryMax,
 	})
+	webhookHandler := handler.NewWebhookHandler(webhookService)
 
-	publicRoute := router.Group("/")
-	{
-		publicRoute.POST("/users/register", userHandler.CreateUserHandler)
-		publicRoute.POST("/users/login", userHandler.LogInUserHandler)
+	// Live expense streams
+	var streamHandler *handler.StreamHandler
+	var broker *stream.Broker
+	if cfg.Features.Streaming {
+		broker = stream.NewBroker(pool.Pool, cfg.Stream.MaxPerUser)
+		eventService := services.NewEventService(outboxRepo)
+		streamHandler = handler.NewStreamHandler(eventService, broker, cfg.Stream.Heartbeat, cfg.CORS.AllowedOrigins)
 	}
 
-	userRoute := router.Group("/")
-	userRoute.Use(middleware.AuthMiddleware())
-	{
-		userRoute.GET("/users/me", userHandler.GetUserHandler)
-		userRoute.POST("/users/expenses", expenseHandler.AddExpenseHandler)
-		userRoute.GET("/users/expenses", expenseHandler.GetAllExpenseHandler)
-		userRoute.GET("/expenses/:id", expenseHandler.GetExpenseByIDHandler)
-		userRoute.PUT("/expenses/:id", expenseHandler.UpdateExpenseHandler)
-		userRoute.DELETE("/expenses/:id", expenseHandler.DeleteExpenseHandler)
+	// GraphQL
+	var graphQLHandler *handler.GraphQLHandler
+	if cfg.Features.GraphQL {
+		schema, err := graph.NewSchema(expenseService, userService, auditService, graph.Options{
+			MaxDepth:      cfg.GraphQL.MaxDepth,
+			MaxComplexity: cfg.GraphQL.MaxComplexity,
+		})
+		if err != nil {
+			slog.Error("failed to build graphql schema", "error", err)
+			os.Exit(1)
+		}
+		graphQLHandler = handler.NewGraphQLHandler(schema)
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // fallback for local
+	idempotencyRepo := repository.NewIdempotencyRepository(pool)
+
+	// migrate before any job starts, so none of them runs against an old schema
+	if cfg.Database.AutoMigrate {
+		migrator, err := migrate.New(pool.Pool, migrations.FS)
+		if err == nil {
+			err = migrator.Up(context.Background())
+		}
+		if err != nil {
+			slog.Error("failed to apply migrations", "error", err)
+			os.Exit(1)
+		}
+	}
+	schemaVersion, err := migrate.Latest(migrations.FS)
+	if err != nil {
+		slog.Error("failed to read embedded migrations", "error", err)
+		os.Exit(1)
+	}
+
+	// Background jobs, stopped on shutdown
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	go reader.Monitor(jobCtx, cfg.Database.ReplicaCheckInterval)
+
+	if cfg.Features.TrashPurge {
+		trashPurger := worker.NewTrashPurger(expenseService, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+		go trashPurger.Run(jobCtx)
+	}
+
+	if cfg.Features.Webhooks {
+		sender := webhook.NewSender(cfg.Webhooks.Timeout, cfg.Webhooks.AllowInsecureTargets)
+		dispatcher := worker.NewWebhookDispatcher(webhookService, sender, cfg.Webhooks.PollInterval,
+			cfg.Webhooks.Timeout, cfg.Webhooks.BatchSize)
+		go dispatcher.Run(jobCtx)
+	}
+
+	// every change writes an outbox event, so purge them even with webhooks off
+	outboxPurger := worker.NewOutboxPurger(webhookService, cfg.Webhooks.Retention, time.Hour, !cfg.Features.Webhooks)
+	go outboxPurger.Run(jobCtx)
+
+	// stopping the broker on shutdown ends every open stream
+	if broker != nil {
+		go broker.Run(jobCtx)
+	}
+
+	idempotencyCleaner := worker.NewIdempotencyCleaner(idempotencyRepo, time.Hour)
+	go idempotencyCleaner.Run(jobCtx)
+
+	healthChecker := health.NewChecker(pool.Pool, schemaVersion, 2*time.Second)
+	healthHandler := handler.NewHealthHandler(healthChecker)
+
+	var limiter ratelimit.Limiter = ratelimit.NewMemoryLimiter()
+	if cfg.RateLimit.Backend == "postgres" {
+		limiter = ratelimit.NewPostgresLimiter(pool.Pool)
+	}
+
+	api, err := router.New(cfg, router.Deps{
+		User:        userHandler,
+		Expense:     expenseHandler,
+		Audit:       auditHandler,
+		Webhook:     webhookHandler,
+		Budget:      budgetHandler,
+		Sync:        syncHandler,
+		Import:      importHandler,
+		Rule:        ruleHandler,
+		Health:      healthHandler,
+		GraphQL:     graphQLHandler,
+		Stream:      streamHandler,
+		Tokens:      tokens,
+		Idempotency: idempotencyRepo,
+		Limiter:     limiter,
+	})
+	if err != nil {
+		slog.Error("failed to build router", "error", err)
+		os.Exit(1)
+	}
+
+	// gRPC API for internal services, on its own port
+	var grpcSrv *grpcapi.Server
+	if cfg.GRPC.Port != "" {
+		grpcSrv, err = grpcapi.New(cfg, grpcapi.Deps{
+			Users:    userService,
+			Expenses: expenseService,
+			Tokens:   tokens,
+		})
+		if err != nil {
+			slog.Error("failed to build grpc server", "error", err)
+			os.Exit(1)
+		}
+		lis, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
+		if err != nil {
+			slog.Error("failed to listen for grpc", "error", err)
+			os.Exit(1)
+		}
+		go func() {
+			slog.Info("grpc server running", "port", cfg.GRPC.Port)
+			if err := grpcSrv.Serve(lis); err != nil {
+				slog.Error("gRPC server crashed", "error", err)
+			}
+		}()
 	}
 
 	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           api,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	// Metrics get their own listener so they are never exposed on the public port
+	var metricsSrv *http.Server
+	if cfg.Metrics.Port != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metrics.Handler())
+		metricsSrv = &http.Server{
+			Addr:              ":" + cfg.Metrics.Port,
+			Handler:           metricsMux,
+			ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		}
+		go func() {
+			slog.Info("metrics server running", "port", cfg.Metrics.Port)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("Metrics server crashed", "error", err)
+			}
+		}()
 	}
 
 	go func() {
-		slog.Info("server running", "port", cfg.Port)
+		slog.Info("server running", "port", cfg.Server.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			slog.Error("Server crashed", "error", err)
 		}
@@ -100,12 +335,38 @@ func main() {
 	<-quit
 	slog.Info(" Shutting down")
 
-	// Give active requests 5 seconds to finish
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Fail readiness first so load balancers stop routing here, then drain.
+	// Waiting only helps if a load balancer last saw this instance ready.
+	// Jobs keep running meanwhile: the instance still serves traffic.
+	healthChecker.SetShuttingDown()
+	if cfg.Server.ShutdownDelay > 0 && healthChecker.ReportedReady() {
+		time.Sleep(cfg.Server.ShutdownDelay)
+	}
+
+	// stop the jobs before draining; the broker's open streams would
+	// otherwise keep the server from ever going idle
+	stopJobs()
+
+	// Give active requests a bounded time to finish
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
+	// drain gRPC calls at the same time, within the same deadline
+	var grpcDone sync.WaitGroup
+	if grpcSrv != nil {
+		grpcDone.Go(func() { grpcSrv.Shutdown(ctx) })
+	}
 	if err := srv.Shutdown(ctx); err != nil {
 		slog.Error("Forced shutdown", "error", err)
 	}
+	grpcDone.Wait()
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			slog.Error("Forced metrics shutdown", "error", err)
+		}
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
 	slog.Info("Server stopped cleanly")
 }
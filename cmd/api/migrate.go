@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/db"
+	"expense-tracker/internal/migrate"
+	"expense-tracker/migrations"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up             apply all pending migrations
+  down [N]       revert the last N migrations (default 1)
+  to <version>   migrate up or down to version (0 reverts everything)
+  status         show the current version and every known migration`
+
+// runMigrate implements the `migrate` subcommand and returns the exit code.
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	pool, err := db.Connect(cfg.Database)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to connect to database:", err)
+		return 1
+	}
+	defer pool.Close()
+
+	migrator, err := migrate.New(pool.Pool, migrations.FS)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				fmt.Fprintln(os.Stderr, "down expects a positive number of steps")
+				return 2
+			}
+		}
+		err = migrator.Down(ctx, steps)
+
+	case "to":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		version, perr := strconv.ParseUint(args[1], 10, 64)
+		if perr != nil {
+			fmt.Fprintln(os.Stderr, "to expects a migration version")
+			return 2
+		}
+		err = migrator.To(ctx, uint(version))
+
+	case "status":
+		var status *migrate.Status
+		status, err = migrator.Status(ctx)
+		if err == nil {
+			printStatus(status)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func printStatus(status *migrate.Status) {
+	dirty := ""
+	if status.Dirty {
+		dirty = " (dirty)"
+	}
+	fmt.Printf("current version: %d%s\n\n", status.Current, dirty)
+	for _, mig := range status.Migrations {
+		state := "pending"
+		if mig.Version <= status.Current {
+			state = "applied"
+		}
+		fmt.Printf("  %-8s %d_%s\n", state, mig.Version, mig.Description)
+	}
+}
@@ -0,0 +1,153 @@
+// Package apperr defines the application's typed errors. Repositories and
+// services return them, and the error middleware renders them as RFC 7807
+// problem details, so handlers never choose status codes or messages for
+// failures themselves.
+package apperr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Kind classifies an error and decides its HTTP status.
+type Kind string
+
+const (
+	KindValidation           Kind = "validation"
+	KindNotFound             Kind = "not_found"
+	KindConflict             Kind = "conflict"
+	KindUnauthorized         Kind = "unauthorized"
+	KindForbidden            Kind = "forbidden"
+	KindPreconditionFailed   Kind = "precondition_failed"
+	KindPreconditionRequired Kind = "precondition_required"
+	KindUnsupportedMediaType Kind = "unsupported_media_type"
+	KindPayloadTooLarge      Kind = "payload_too_large"
+	KindUnprocessable        Kind = "unprocessable"
+	KindTooManyRequests      Kind = "too_many_requests"
+	KindUnavailable          Kind = "unavailable"
+	KindInternal             Kind = "internal"
+)
+
+var statuses = map[Kind]int{
+	KindValidation:           http.StatusBadRequest,
+	KindNotFound:             http.StatusNotFound,
+	KindConflict:             http.StatusConflict,
+	KindUnauthorized:         http.StatusUnauthorized,
+	KindForbidden:            http.StatusForbidden,
+	KindPreconditionFailed:   http.StatusPreconditionFailed,
+	KindPreconditionRequired: http.StatusPreconditionRequired,
+	KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	KindPayloadTooLarge:      http.StatusRequestEntityTooLarge,
+	KindUnprocessable:        http.StatusUnprocessableEntity,
+	KindTooManyRequests:      http.StatusTooManyRequests,
+	KindUnavailable:          http.StatusServiceUnavailable,
+	KindInternal:             http.StatusInternalServerError,
+}
+
+// Status is the HTTP status code for the kind.
+func (k Kind) Status() int {
+	if status, ok := statuses[k]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldError describes one invalid input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Field is shorthand for a FieldError.
+func Field(field, message string) FieldError {
+	return FieldError{Field: field, Message: message}
+}
+
+// Error is a failure with a client-safe Message. The wrapped Err carries the
+// underlying cause for logs and errors.Is/As; it is never sent to clients.
+type Error struct {
+	Kind    Kind
+	Message string
+	Fields  []FieldError
+	// Extensions are extra problem members, e.g. per-operation batch results.
+	Extensions map[string]any
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// With returns a copy of e carrying an extra problem member.
+func (e *Error) With(key string, value any) *Error {
+	copied := *e
+	copied.Extensions = make(map[string]any, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		copied.Extensions[k] = v
+	}
+	copied.Extensions[key] = value
+	return &copied
+}
+
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Validation reports invalid input, optionally per field.
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Message: message, Fields: fields}
+}
+
+func NotFound(message string) *Error {
+	return New(KindNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(KindConflict, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(KindUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(KindForbidden, message)
+}
+
+// Unavailable reports a temporary overload; clients should retry later.
+func Unavailable(message string) *Error {
+	return New(KindUnavailable, message)
+}
+
+// Internal hides cause behind a generic message.
+func Internal(cause error) *Error {
+	return &Error{Kind: KindInternal, Message: "internal server error", Err: cause}
+}
+
+// From returns err as an *Error, treating anything untyped as internal. An
+// expired request deadline is reported as unavailable rather than a bug.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		e := Unavailable("the request took too long, retry shortly")
+		e.Err = err
+		return e
+	}
+	return Internal(err)
+}
+
+// KindOf returns the kind of err, or KindInternal for untyped errors.
+func KindOf(err error) Kind {
+	return From(err).Kind
+}
@@ -0,0 +1,63 @@
+package apperr
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body. Code and Errors are extension
+// members: Code is the error kind for clients to switch on, Errors lists
+// invalid fields.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      Kind         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Extensions are merged into the top-level object.
+	Extensions map[string]any `json:"-"`
+}
+
+// MarshalJSON flattens Extensions next to the standard members, which win on
+// a name clash.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type plain Problem
+	if len(p.Extensions) == 0 {
+		return json.Marshal(plain(p))
+	}
+	base, err := json.Marshal(plain(p))
+	if err != nil {
+		return nil, err
+	}
+	members := map[string]any{}
+	if err := json.Unmarshal(base, &members); err != nil {
+		return nil, err
+	}
+	merged := maps.Clone(p.Extensions)
+	maps.Copy(merged, members)
+	return json.Marshal(merged)
+}
+
+// Problem renders e for a request to instance. Without a problem type
+// registry the type is about:blank, so the title is the status text.
+func (e *Error) Problem(instance string) Problem {
+	status := e.Kind.Status()
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   e.Message,
+		Instance: instance,
+		Code:     e.Kind,
+		Errors:   e.Fields,
+
+		Extensions: e.Extensions,
+	}
+}
@@ -1,39 +1,226 @@
+// Package config loads the application's settings.
+//
+// Every setting has a dotted key (e.g. server.read_timeout), an environment
+// variable and a default, declared in the struct tags below. Sources are
+// applied in increasing order of precedence:
+//
+//  1. defaults from the `default` tags
+//  2. a YAML (.yaml/.yml) or TOML (.toml) file named by -config or CONFIG_FILE
+//  3. environment variables, including a .env file in the working directory
+//  4. command-line flags, one per key (e.g. -server.read_timeout=10s)
+//
+// The merged result is validated before it is returned.
 package config
 
-import (
-	"fmt"
-	"os"
-
-	"github.com/joho/godotenv"
-)
+import "time"
 
 type Config struct {
-	DatabaseURL string
-	Port        string
-	JwtSecret   string
-}
-
-func Load() (*Config, error) {
-	_ = godotenv.Load() // silently ignore if .env not found
-
-	cfg := &Config{
-		DatabaseURL: os.Getenv("DATABASE_URL"),
-		Port:        os.Getenv("PORT"),
-		JwtSecret:   os.Getenv("JWT_SECRET"),
-	}
-
-	// Validate required fields
-	if cfg.DatabaseURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL is required")
-	}
-	if cfg.JwtSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET is required")
-	}
-
-	// Default port
-	if cfg.Port == "" {
-		cfg.Port = "8080"
-	}
-
-	return cfg, nil
+	Server      ServerConfig      `key:"server"`
+	Database    DatabaseConfig    `key:"database"`
+	JWT         JWTConfig         `key:"jwt"`
+	CORS        CORSConfig        `key:"cors"`
+	Log         LogConfig         `key:"log"`
+	Metrics     MetricsConfig     `key:"metrics"`
+	Tracing     TracingConfig     `key:"tracing"`
+	RateLimit   RateLimitConfig   `key:"rate_limit"`
+	Idempotency IdempotencyConfig `key:"idempotency"`
+	Trash       TrashConfig       `key:"trash"`
+	OpenAPI     OpenAPIConfig     `key:"openapi"`
+	GraphQL     GraphQLConfig     `key:"graphql"`
+	GRPC        GRPCConfig        `key:"grpc"`
+	Webhooks    WebhookConfig     `key:"webhooks"`
+	Stream      StreamConfig      `key:"stream"`
+	Import      ImportConfig      `key:"import"`
+	Features    FeatureFlags      `key:"features"`
+}
+
+type ServerConfig struct {
+	Port              string        `key:"port" env:"PORT" default:"8080" help:"HTTP listen port"`
+	ReadTimeout       time.Duration `key:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"15s" help:"maximum time to read a whole request"`
+	ReadHeaderTimeout time.Duration `key:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" default:"5s" help:"maximum time to read request headers"`
+	WriteTimeout      time.Duration `key:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30s" help:"maximum time to write a response"`
+	IdleTimeout       time.Duration `key:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"60s" help:"keep-alive idle timeout"`
+	ShutdownTimeout   time.Duration `key:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" default:"5s" help:"time given to in-flight requests on shutdown"`
+	// ShutdownDelay is how long /readyz fails before the server starts
+	// draining, giving load balancers time to stop sending traffic. It is
+	// skipped when the instance never reported ready; zero drains straight away.
+	ShutdownDelay  time.Duration `key:"shutdown_delay" env:"SHUTDOWN_DELAY" default:"5s" help:"time /readyz fails before draining starts"`
+	TrustedProxies []string      `key:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES" help:"comma-separated proxy CIDRs whose X-Forwarded-For is trusted"`
+	// MaxBodyBytes caps request bodies before anything reads them; statement
+	// uploads are capped by import.max_file_bytes instead.
+	MaxBodyBytes int `key:"max_body_bytes" env:"SERVER_MAX_BODY_BYTES" default:"1048576" help:"largest request body accepted, in bytes"`
+	// RequestTimeout bounds the work a handler does for one request;
+	// RouteTimeouts overrides it for slower routes, as "METHOD /path=duration"
+	// entries using the router's path pattern.
+	RequestTimeout time.Duration `key:"request_timeout" env:"SERVER_REQUEST_TIMEOUT" default:"5s" help:"deadline for handling one request"`
+	RouteTimeouts  []string      `key:"route_timeouts" env:"SERVER_ROUTE_TIMEOUTS" default:"POST /api/v1/expenses/batch=15s,POST /users/expenses/batch=15s,POST /api/v1/sync=15s,POST /api/v1/rules/apply=25s,POST /api/v1/imports=25s,POST /api/v1/imports/:id/review=25s" help:"comma-separated METHOD /path=duration overrides"`
+	// LegacySunset is announced in the Sunset header of the deprecated
+	// unversioned routes, as a YYYY-MM-DD date.
+	LegacySunset string `key:"legacy_sunset" env:"SERVER_LEGACY_SUNSET" default:"2027-04-30" help:"date (YYYY-MM-DD) the unversioned routes go away"`
+}
+
+type DatabaseConfig struct {
+	URL      string `key:"url" env:"DATABASE_URL" secret:"true" help:"Postgres connection string (required)"`
+	MaxConns int    `key:"max_conns" env:"DB_MAX_CONNS" default:"10" help:"maximum pool size"`
+	MinConns int    `key:"min_conns" env:"DB_MIN_CONNS" default:"0" help:"connections kept open when idle"`
+	// MaxConnLifetime recycles connections so they pick up DNS or failover
+	// changes; MaxConnIdleTime closes connections above MinConns that sit unused.
+	MaxConnLifetime   time.Duration `key:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME" default:"1h"`
+	MaxConnIdleTime   time.Duration `key:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME" default:"30m"`
+	HealthCheckPeriod time.Duration `key:"health_check_period" env:"DB_HEALTH_CHECK_PERIOD" default:"1m" help:"how often idle connections are checked"`
+	// StatementTimeout is set as the session statement_timeout on every
+	// connection so a runaway query cannot hold a connection forever. Zero
+	// disables it. Migrations run without it.
+	StatementTimeout time.Duration `key:"statement_timeout" env:"DB_STATEMENT_TIMEOUT" default:"10s" help:"server-side limit per statement; 0 disables"`
+	// AcquireTimeout is how long a query waits for a free connection before
+	// failing with 503 Service Unavailable.
+	AcquireTimeout time.Duration `key:"acquire_timeout" env:"DB_ACQUIRE_TIMEOUT" default:"2s" help:"wait for a free pool connection"`
+	// ReplicaURL optionally names a streaming replica for listing and report
+	// queries. Reads fall back to the primary while the replica is down or
+	// lags more than ReplicaMaxLag.
+	ReplicaURL           string        `key:"replica_url" env:"DATABASE_REPLICA_URL" secret:"true" help:"read replica connection string; empty sends all reads to the primary"`
+	ReplicaMaxLag        time.Duration `key:"replica_max_lag" env:"DB_REPLICA_MAX_LAG" default:"10s" help:"replay lag above which reads go to the primary"`
+	ReplicaCheckInterval time.Duration `key:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL" default:"5s"`
+	// ReadYourWritesWindow pins a user's reads to the primary for this long
+	// after they change something, so they see their own writes.
+	ReadYourWritesWindow time.Duration `key:"read_your_writes_window" env:"DB_READ_YOUR_WRITES_WINDOW" default:"5s"`
+	// ConnectTimeout is how long startup keeps retrying while Postgres is unreachable.
+	ConnectTimeout time.Duration `key:"connect_timeout" env:"DB_CONNECT_TIMEOUT" default:"30s" help:"how long to retry the initial connection"`
+	// AutoMigrate applies pending embedded migrations on startup. Replicas
+	// serialize on an advisory lock, so it is safe to enable everywhere.
+	AutoMigrate bool `key:"auto_migrate" env:"AUTO_MIGRATE" default:"false" help:"apply pending migrations on startup"`
+	// TxIsolation is the default isolation level for multi-statement units of work.
+	TxIsolation  string `key:"tx_isolation" env:"TX_ISOLATION" default:"read committed" help:"default transaction isolation level"`
+	TxMaxRetries int    `key:"tx_max_retries" env:"TX_MAX_RETRIES" default:"3" help:"retries after a serialization failure"`
+}
+
+type JWTConfig struct {
+	Secret string        `key:"secret" env:"JWT_SECRET" secret:"true" help:"HMAC signing key, at least 16 bytes (required)"`
+	TTL    time.Duration `key:"ttl" env:"JWT_TTL" default:"24h" help:"lifetime of issued tokens"`
+	Issuer string        `key:"issuer" env:"JWT_ISSUER" help:"iss claim set and required on tokens; empty disables the check"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins empty disables CORS; "*" allows any origin.
+	AllowedOrigins   []string      `key:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" help:"comma-separated origins allowed to call the API"`
+	AllowedMethods   []string      `key:"allowed_methods" env:"CORS_ALLOWED_METHODS" default:"GET,POST,PUT,PATCH,DELETE,OPTIONS"`
+	AllowedHeaders   []string      `key:"allowed_headers" env:"CORS_ALLOWED_HEADERS" default:"Authorization,Content-Type,If-Match,If-None-Match,Idempotency-Key,Last-Event-ID,X-Request-ID"`
+	ExposedHeaders   []string      `key:"exposed_headers" env:"CORS_EXPOSED_HEADERS" default:"ETag,X-Request-ID,Retry-After,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Deprecation,Sunset,Link"`
+	AllowCredentials bool          `key:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" default:"false"`
+	MaxAge           time.Duration `key:"max_age" env:"CORS_MAX_AGE" default:"10m" help:"how long browsers may cache preflight responses"`
+}
+
+type LogConfig struct {
+	Format string `key:"format" env:"LOG_FORMAT" default:"text" help:"text or json"`
+	Level  string `key:"level" env:"LOG_LEVEL" default:"info" help:"debug, info, warn or error"`
+}
+
+type MetricsConfig struct {
+	// Port serves /metrics on its own listener so it can stay off the public
+	// network. Empty disables the metrics server.
+	Port string `key:"port" env:"METRICS_PORT" help:"metrics listen port; empty disables metrics"`
+}
+
+type TracingConfig struct {
+	// Endpoint is the OTLP/HTTP collector (host:port); empty disables tracing.
+	Endpoint    string  `key:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" help:"OTLP/HTTP collector host:port; empty disables tracing"`
+	Insecure    bool    `key:"insecure" env:"OTEL_EXPORTER_OTLP_INSECURE" default:"false" help:"send spans over plain HTTP"`
+	SampleRatio float64 `key:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG" default:"1" help:"fraction of new traces recorded"`
+	ServiceName string  `key:"service_name" env:"OTEL_SERVICE_NAME" default:"expense-tracker"`
+}
+
+type RateLimitConfig struct {
+	// Backend is "memory" (per instance) or "postgres" (shared by replicas).
+	Backend string `key:"backend" env:"RATE_LIMIT_BACKEND" default:"memory" help:"memory or postgres"`
+	// Auth and API are "<requests>/<duration>" specs for the login/register
+	// routes and the authenticated API respectively.
+	Auth string `key:"auth" env:"RATE_LIMIT_AUTH" default:"10/1m" help:"limit for login and register, per client IP"`
+	API  string `key:"api" env:"RATE_LIMIT_API" default:"300/1m" help:"limit for the authenticated API, per user"`
+}
+
+type IdempotencyConfig struct {
+	// TTL is how long a response stored under an Idempotency-Key is replayed.
+	TTL time.Duration `key:"ttl" env:"IDEMPOTENCY_TTL" default:"24h" help:"how long idempotent responses are replayed"`
+}
+
+type TrashConfig struct {
+	// Retention is how long a soft-deleted expense stays restorable before it is purged.
+	Retention time.Duration `key:"retention" env:"TRASH_RETENTION" default:"720h" help:"how long deleted expenses stay restorable"`
+	// PurgeInterval is how often the purger looks for expired trash.
+	PurgeInterval time.Duration `key:"purge_interval" env:"TRASH_PURGE_INTERVAL" default:"1h"`
+}
+
+type OpenAPIConfig struct {
+	// ValidateRequests rejects requests that don't match the OpenAPI document
+	// before they reach a handler.
+	ValidateRequests bool `key:"validate_requests" env:"OPENAPI_VALIDATE_REQUESTS" default:"true" help:"reject requests that don't match the OpenAPI document"`
+	// ValidateResponses logs responses that don't match the document. It
+	// copies every response body, so leave it off in production.
+	ValidateResponses bool `key:"validate_responses" env:"OPENAPI_VALIDATE_RESPONSES" default:"false" help:"log responses that don't match the OpenAPI document; for test environments"`
+}
+
+type GraphQLConfig struct {
+	// MaxDepth is the deepest field nesting a query may have.
+	MaxDepth int `key:"max_depth" env:"GRAPHQL_MAX_DEPTH" default:"8"`
+	// MaxComplexity caps a query's estimated cost: one per field, with
+	// fields under a list counted once per expected item.
+	MaxComplexity int `key:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY" default:"1000" help:"reject queries whose estimated cost is higher"`
+}
+
+type GRPCConfig struct {
+	// Port serves the gRPC API for internal services. Empty, the default,
+	// disables it.
+	Port string `key:"port" env:"GRPC_PORT" help:"gRPC listen port; empty disables the gRPC server"`
+	// APIKeys authenticate internal services, as "name=key" pairs. A service
+	// acts for the user named in its x-user-id metadata.
+	APIKeys []string `key:"api_keys" env:"GRPC_API_KEYS" secret:"true" help:"comma-separated name=key pairs for service callers"`
+	// Reflection lets tools like grpcurl discover the services.
+	Reflection bool `key:"reflection" env:"GRPC_REFLECTION" default:"false"`
+}
+
+type WebhookConfig struct {
+	// PollInterval is how often the dispatcher looks for new events and due deliveries.
+	PollInterval time.Duration `key:"poll_interval" env:"WEBHOOK_POLL_INTERVAL" default:"2s"`
+	// Timeout bounds one delivery attempt, connect to last byte.
+	Timeout     time.Duration `key:"timeout" env:"WEBHOOK_TIMEOUT" default:"10s"`
+	BatchSize   int           `key:"batch_size" env:"WEBHOOK_BATCH_SIZE" default:"50" help:"events dispatched and deliveries sent per poll"`
+	MaxAttempts int           `key:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" default:"8" help:"attempts before a delivery is marked dead"`
+	// RetryBase is the delay after the first failure; it doubles with every
+	// further failure up to RetryMax.
+	RetryBase time.Duration `key:"retry_base" env:"WEBHOOK_RETRY_BASE" default:"30s"`
+	RetryMax  time.Duration `key:"retry_max" env:"WEBHOOK_RETRY_MAX" default:"6h"`
+	// Retention is how long dispatched events and their deliveries are kept;
+	// with webhooks off, how long events are kept after they are written.
+	Retention time.Duration `key:"retention" env:"WEBHOOK_RETENTION" default:"720h"`
+	// AllowInsecureTargets permits plain http and private or loopback
+	// addresses. Only for local development.
+	AllowInsecureTargets bool `key:"allow_insecure_targets" env:"WEBHOOK_ALLOW_INSECURE_TARGETS" default:"false" help:"allow http and private webhook targets; development only"`
+}
+
+type StreamConfig struct {
+	// Heartbeat is how often a quiet stream sends a comment (SSE) or ping
+	// (WebSocket), so proxies keep it open and clients notice a dead one.
+	Heartbeat time.Duration `key:"heartbeat" env:"STREAM_HEARTBEAT" default:"15s"`
+	// MaxPerUser caps the streams one user may hold open on an instance.
+	MaxPerUser int `key:"max_per_user" env:"STREAM_MAX_PER_USER" default:"5" help:"open expense streams allowed per user and instance"`
+}
+
+type ImportConfig struct {
+	// MaxFileBytes caps the size of an uploaded bank statement.
+	MaxFileBytes int `key:"max_file_bytes" env:"IMPORT_MAX_FILE_BYTES" default:"5242880" help:"largest bank statement file accepted, in bytes"`
+}
+
+// FeatureFlags switch optional subsystems on or off.
+type FeatureFlags struct {
+	RateLimiting bool `key:"rate_limiting" env:"FEATURE_RATE_LIMITING" default:"true"`
+	Idempotency  bool `key:"idempotency" env:"FEATURE_IDEMPOTENCY" default:"true"`
+	TrashPurge   bool `key:"trash_purge" env:"FEATURE_TRASH_PURGE" default:"true"`
+	// LegacyRoutes keeps the pre-/api/v1 paths as deprecated aliases.
+	LegacyRoutes bool `key:"legacy_routes" env:"FEATURE_LEGACY_ROUTES" default:"true"`
+	GraphQL      bool `key:"graphql" env:"FEATURE_GRAPHQL" default:"true"`
+	// Webhooks runs the dispatcher; events are recorded either way.
+	Webhooks bool `key:"webhooks" env:"FEATURE_WEBHOOKS" default:"true"`
+	// Streaming serves the live expense streams; it holds one extra
+	// database connection for LISTEN.
+	Streaming bool `key:"streaming" env:"FEATURE_STREAMING" default:"true"`
 }
@@ -0,0 +1,294 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// field is one leaf setting discovered from the Config struct tags.
+type field struct {
+	key    string // dotted key, e.g. server.port
+	env    string
+	def    string
+	help   string
+	secret bool
+	value  reflect.Value
+}
+
+// source is one layer of settings keyed by dotted key, applied over the
+// ones before it.
+type source struct {
+	name   string
+	values map[string]string
+}
+
+// Load builds the configuration from defaults, the config file, the
+// environment and the command-line flags in args (usually os.Args[1:]), then
+// validates it. It returns the arguments left after flag parsing, so callers
+// can dispatch subcommands. A -h/-help flag returns flag.ErrHelp.
+func Load(args []string) (*Config, []string, error) {
+	_ = godotenv.Load() // silently ignore if .env not found
+
+	cfg := &Config{}
+	fields := collect(reflect.ValueOf(cfg).Elem(), "")
+
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+		if err := set(f.value, f.def); err != nil {
+			return nil, nil, fmt.Errorf("default for %s: %w", f.key, err)
+		}
+	}
+
+	// Flags are parsed first to find -config, but applied last
+	fs := flag.NewFlagSet("expense-tracker", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file (env CONFIG_FILE)")
+	flagValues := map[string]string{}
+	for _, f := range fields {
+		key := f.key
+		usage := f.help
+		if f.env != "" {
+			usage = strings.TrimSpace(usage + " (env " + f.env + ")")
+		}
+		fs.Func(key, usage, func(s string) error {
+			flagValues[key] = s
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	var sources []source
+	if *configFile != "" {
+		values, err := readFile(*configFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources = append(sources, source{name: *configFile, values: values})
+	}
+	sources = append(sources,
+		source{name: "environment", values: envValues(fields)},
+		source{name: "flags", values: flagValues},
+	)
+
+	index := make(map[string]field, len(fields))
+	for _, f := range fields {
+		index[f.key] = f
+	}
+	for _, src := range sources {
+		for key, raw := range src.values {
+			f, ok := index[key]
+			if !ok {
+				return nil, nil, fmt.Errorf("%s: unknown setting %q", src.name, key)
+			}
+			if err := set(f.value, raw); err != nil {
+				return nil, nil, fmt.Errorf("%s: %s: %w", src.name, key, err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// collect walks the Config struct and returns its leaf settings in declaration order.
+func collect(v reflect.Value, prefix string) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("key")
+		if key == "" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeOf(time.Duration(0)) {
+			fields = append(fields, collect(v.Field(i), key)...)
+			continue
+		}
+		fields = append(fields, field{
+			key:    key,
+			env:    sf.Tag.Get("env"),
+			def:    sf.Tag.Get("default"),
+			help:   sf.Tag.Get("help"),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+	return fields
+}
+
+// envValues returns the settings present in the environment, keyed by dotted key.
+// Empty variables are ignored so that an unset-but-exported variable keeps the default.
+func envValues(fields []field) map[string]string {
+	values := map[string]string{}
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if raw := os.Getenv(f.env); raw != "" {
+			values[f.key] = raw
+		}
+	}
+	return values
+}
+
+// readFile decodes a YAML or TOML file, chosen by extension, into dotted keys.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	var tree map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flatten(tree, "", values); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(tree map[string]any, prefix string, out map[string]string) error {
+	for k, v := range tree {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flatten(v, key, out); err != nil {
+				return err
+			}
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+			// an empty value in the file leaves the default in place
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// set parses raw into the setting according to its Go type.
+func set(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// Redacted returns every setting as key/value strings in declaration order,
+// with secrets masked. Connection URLs keep everything but the password.
+func (c *Config) Redacted() [][2]string {
+	fields := collect(reflect.ValueOf(c).Elem(), "")
+	out := make([][2]string, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, [2]string{f.key, redact(f)})
+	}
+	return out
+}
+
+func redact(f field) string {
+	var s string
+	switch v := f.value.Interface().(type) {
+	case []string:
+		s = strings.Join(v, ",")
+	default:
+		s = fmt.Sprint(v)
+	}
+	if !f.secret || s == "" {
+		return s
+	}
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" {
+		return u.Redacted()
+	}
+	return "******"
+}
+
+// LogValue lets the config be logged directly as a redacted group.
+func (c *Config) LogValue() slog.Value {
+	pairs := c.Redacted()
+	attrs := make([]slog.Attr, len(pairs))
+	for i, p := range pairs {
+		attrs[i] = slog.String(p[0], p[1])
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Print writes the redacted effective configuration, one key = value per line.
+func (c *Config) Print(w io.Writer) {
+	for _, p := range c.Redacted() {
+		fmt.Fprintf(w, "%s = %s\n", p[0], p[1])
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"errors"
+	"expense-tracker/internal/ratelimit"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate checks every setting and reports all problems at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	positive := func(key string, d time.Duration) {
+		check(d > 0, "%s must be a positive duration, got %s", key, d)
+	}
+
+	// server
+	check(validPort(c.Server.Port), "server.port must be a port number, got %q", c.Server.Port)
+	positive("server.read_timeout", c.Server.ReadTimeout)
+	positive("server.read_header_timeout", c.Server.ReadHeaderTimeout)
+	positive("server.write_timeout", c.Server.WriteTimeout)
+	positive("server.idle_timeout", c.Server.IdleTimeout)
+	positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay must not be negative")
+	positive("server.request_timeout", c.Server.RequestTimeout)
+	check(c.Server.MaxBodyBytes > 0, "server.max_body_bytes must be positive, got %d", c.Server.MaxBodyBytes)
+	if _, err := c.Server.RouteTimeoutOverrides(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := c.Server.LegacySunsetDate(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil, "server.trusted_proxies: %q is not an IP or CIDR", proxy)
+	}
+
+	// database
+	check(c.Database.URL != "", "database.url (DATABASE_URL) is required")
+	check(c.Database.MaxConns > 0, "database.max_conns must be at least 1, got %d", c.Database.MaxConns)
+	check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns,
+		"database.min_conns must be between 0 and database.max_conns, got %d", c.Database.MinConns)
+	positive("database.max_conn_lifetime", c.Database.MaxConnLifetime)
+	positive("database.max_conn_idle_time", c.Database.MaxConnIdleTime)
+	positive("database.health_check_period", c.Database.HealthCheckPeriod)
+	check(c.Database.StatementTimeout >= 0, "database.statement_timeout must not be negative")
+	positive("database.acquire_timeout", c.Database.AcquireTimeout)
+	positive("database.connect_timeout", c.Database.ConnectTimeout)
+	check(c.Database.ReplicaURL == "" || c.Database.ReplicaURL != c.Database.URL, "database.replica_url must differ from database.url")
+	positive("database.replica_max_lag", c.Database.ReplicaMaxLag)
+	positive("database.replica_check_interval", c.Database.ReplicaCheckInterval)
+	check(c.Database.ReadYourWritesWindow >= 0, "database.read_your_writes_window must not be negative")
+	check(slices.Contains([]string{"", "serializable", "repeatable read", "read committed", "read uncommitted"}, c.Database.TxIsolation),
+		"database.tx_isolation must be serializable, repeatable read, read committed or read uncommitted, got %q", c.Database.TxIsolation)
+	check(c.Database.TxMaxRetries >= 0, "database.tx_max_retries must not be negative")
+
+	// jwt
+	check(c.JWT.Secret != "", "jwt.secret (JWT_SECRET) is required")
+	check(c.JWT.Secret == "" || len(c.JWT.Secret) >= 16, "jwt.secret must be at least 16 bytes")
+	positive("jwt.ttl", c.JWT.TTL)
+
+	// cors
+	check(!(c.CORS.AllowCredentials && slices.Contains(c.CORS.AllowedOrigins, "*")),
+		"cors.allow_credentials cannot be combined with a wildcard origin")
+	check(c.CORS.MaxAge >= 0, "cors.max_age must not be negative")
+
+	// log
+	check(slices.Contains([]string{"text", "json"}, c.Log.Format), "log.format must be text or json, got %q", c.Log.Format)
+	check(slices.Contains([]string{"debug", "info", "warn", "error"}, strings.ToLower(c.Log.Level)),
+		"log.level must be debug, info, warn or error, got %q", c.Log.Level)
+
+	// metrics
+	check(c.Metrics.Port == "" || validPort(c.Metrics.Port), "metrics.port must be a port number, got %q", c.Metrics.Port)
+	check(c.Metrics.Port == "" || c.Metrics.Port != c.Server.Port, "metrics.port must differ from server.port")
+
+	// tracing
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"tracing.sample_ratio must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	check(c.Tracing.ServiceName != "", "tracing.service_name must not be empty")
+
+	// rate limiting
+	check(c.RateLimit.Backend == "memory" || c.RateLimit.Backend == "postgres",
+		"rate_limit.backend must be memory or postgres, got %q", c.RateLimit.Backend)
+	if _, err := ratelimit.ParsePolicy("auth", c.RateLimit.Auth); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := ratelimit.ParsePolicy("api", c.RateLimit.API); err != nil {
+		errs = append(errs, err)
+	}
+
+	// grpc
+	check(c.GRPC.Port == "" || validPort(c.GRPC.Port), "grpc.port must be a port number, got %q", c.GRPC.Port)
+	check(c.GRPC.Port == "" || (c.GRPC.Port != c.Server.Port && c.GRPC.Port != c.Metrics.Port),
+		"grpc.port must differ from server.port and metrics.port")
+	if _, err := c.GRPC.APIKeySet(); err != nil {
+		errs = append(errs, err)
+	}
+
+	positive("idempotency.ttl", c.Idempotency.TTL)
+	positive("trash.retention", c.Trash.Retention)
+	positive("trash.purge_interval", c.Trash.PurgeInterval)
+	check(c.GraphQL.MaxDepth > 0, "graphql.max_depth must be positive, got %d", c.GraphQL.MaxDepth)
+	check(c.GraphQL.MaxComplexity > 0, "graphql.max_complexity must be positive, got %d", c.GraphQL.MaxComplexity)
+	positive("webhooks.poll_interval", c.Webhooks.PollInterval)
+	positive("webhooks.timeout", c.Webhooks.Timeout)
+	positive("webhooks.retry_base", c.Webhooks.RetryBase)
+	positive("webhooks.retention", c.Webhooks.Retention)
+	check(c.Webhooks.RetryMax >= c.Webhooks.RetryBase, "webhooks.retry_max must not be below webhooks.retry_base")
+	check(c.Webhooks.BatchSize > 0, "webhooks.batch_size must be positive, got %d", c.Webhooks.BatchSize)
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts must be positive, got %d", c.Webhooks.MaxAttempts)
+	positive("stream.heartbeat", c.Stream.Heartbeat)
+	check(c.Stream.MaxPerUser > 0, "stream.max_per_user must be positive, got %d", c.Stream.MaxPerUser)
+	check(c.Import.MaxFileBytes > 0, "import.max_file_bytes must be positive, got %d", c.Import.MaxFileBytes)
+
+	return errors.Join(errs...)
+}
+
+// RouteTimeoutOverrides parses RouteTimeouts into a map keyed by "METHOD /path".
+func (s ServerConfig) RouteTimeoutOverrides() (map[string]time.Duration, error) {
+	overrides := make(map[string]time.Duration, len(s.RouteTimeouts))
+	for _, entry := range s.RouteTimeouts {
+		route, raw, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath || !strings.HasPrefix(strings.TrimSpace(path), "/") {
+			return nil, fmt.Errorf("server.route_timeouts: expected METHOD /path=duration, got %q", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("server.route_timeouts: %q must have a positive duration", entry)
+		}
+		overrides[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = d
+	}
+	return overrides, nil
+}
+
+// APIKeySet parses APIKeys into a map from key to service name.
+func (g GRPCConfig) APIKeySet() (map[string]string, error) {
+	keys := make(map[string]string, len(g.APIKeys))
+	for _, entry := range g.APIKeys {
+		name, key, ok := strings.Cut(entry, "=")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("grpc.api_keys: expected name=key, got an entry without a name")
+		}
+		if len(key) < 16 {
+			return nil, fmt.Errorf("grpc.api_keys: the key for %q must be at least 16 bytes", name)
+		}
+		if _, dup := keys[key]; dup {
+			return nil, fmt.Errorf("grpc.api_keys: the key for %q is also used by %q", name, keys[key])
+		}
+		keys[key] = name
+	}
+	return keys, nil
+}
+
+// LegacySunsetDate parses LegacySunset as midnight UTC of that day.
+func (s ServerConfig) LegacySunsetDate() (time.Time, error) {
+	date, err := time.Parse(time.DateOnly, s.LegacySunset)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("server.legacy_sunset must be a YYYY-MM-DD date, got %q", s.LegacySunset)
+	}
+	return date, nil
+}
+
+func validPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n <= 65535
+}
@@ -2,38 +2,82 @@ package db
 
 import (
 	"context"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/tracing"
+	"fmt"
 	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func Connect(databaseURL string) (*pgxpool.Pool, error) {
+// Connect opens the connection pool described by cfg. Postgres often comes up
+// after the API (e.g. under docker-compose), so the first ping is retried with
+// exponential backoff until cfg.ConnectTimeout has passed.
+func Connect(cfg config.DatabaseConfig) (*Pool, error) {
 
-	config, err := pgxpool.ParseConfig(databaseURL)
+	pool, err := newPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ping(pool.Pool, cfg.ConnectTimeout); err != nil {
+		slog.Error("unable to ping the database", "error", err)
+		pool.Close() //shuts down the database connection pool
+		return nil, err
+	}
+	slog.Info("Successfully connected to postgres database", "max_conns", cfg.MaxConns)
+	return pool, nil
+}
+
+// newPool builds the pool for cfg.URL without connecting; pgxpool dials lazily.
+func newPool(cfg config.DatabaseConfig) (*Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL)
 
 	if err != nil {
 		slog.Warn("unable to parse databaseURL", "error", err)
 		return nil, err
 	}
+	poolConfig.MaxConns = int32(cfg.MaxConns)
+	poolConfig.MinConns = int32(cfg.MinConns)
+	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	if cfg.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
 
-	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 
 	if err != nil {
 		slog.Error("Unable to create the connection pool", "error", err)
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel() // cleans up a context to prevent leaks
+	return &Pool{Pool: pool, acquireTimeout: cfg.AcquireTimeout}, nil
+}
 
-	err = pool.Ping(ctx)
+// ping retries until the database answers or wait has passed, backing off
+// from 250ms up to 5s between attempts.
+func ping(pool *pgxpool.Pool, wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	backoff := 250 * time.Millisecond
 
-	if err != nil {
-		slog.Error("unable to ping the database", "error", err)
-		pool.Close() //shuts down the database connection pool
-		return nil, err
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := pool.Ping(ctx)
+		cancel() // cleans up a context to prevent leaks
+		if err == nil {
+			return nil
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		}
+		slog.Warn("database not ready, retrying", "attempt", attempt, "backoff", backoff, "error", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, 5*time.Second)
 	}
-	slog.Info("Successfully connected to postgres database")
-	return pool, nil
 }
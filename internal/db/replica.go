@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/metrics"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type primaryKey struct{}
+
+// WithPrimary marks ctx so that reads made with it go to the primary, e.g.
+// right after the caller changed data the replica may not have replayed yet.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func primaryRequested(ctx context.Context) bool {
+	pinned, _ := ctx.Value(primaryKey{}).(bool)
+	return pinned
+}
+
+// ConnectReplica opens the read replica pool with the same pool settings as
+// the primary. Unlike Connect it does not wait for the replica: reads use the
+// primary until ReadRouter.Monitor has seen the replica healthy.
+func ConnectReplica(cfg config.DatabaseConfig) (*Pool, error) {
+	cfg.URL = cfg.ReplicaURL
+	pool, err := newPool(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("replica: %w", err)
+	}
+	return pool, nil
+}
+
+// ReadRouter sends read-only queries to the replica when one is configured,
+// healthy and not lagging, and to the primary otherwise. A read the replica
+// cannot serve is retried on the primary. It implements the same
+// Exec/Query/QueryRow methods as Pool; Exec always runs on the primary.
+type ReadRouter struct {
+	primary *Pool
+	replica *Pool
+	maxLag  time.Duration
+	healthy atomic.Bool
+}
+
+// NewReadRouter routes reads between primary and replica. replica may be nil,
+// in which case every read goes to the primary.
+func NewReadRouter(primary, replica *Pool, maxLag time.Duration) *ReadRouter {
+	return &ReadRouter{primary: primary, replica: replica, maxLag: maxLag}
+}
+
+func (r *ReadRouter) pick(ctx context.Context) *Pool {
+	if r.replica == nil || primaryRequested(ctx) || !r.healthy.Load() {
+		metrics.DBReads.WithLabelValues("primary").Inc()
+		return r.primary
+	}
+	metrics.DBReads.WithLabelValues("replica").Inc()
+	return r.replica
+}
+
+func (r *ReadRouter) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return r.primary.Exec(ctx, sql, args...)
+}
+
+// Query runs on the replica when it is picked; if the replica turns out to
+// be unavailable the query is retried once on the primary and the replica is
+// taken out of rotation until Monitor sees it healthy again.
+func (r *ReadRouter) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	pool := r.pick(ctx)
+	rows, err := pool.Query(ctx, sql, args...)
+	if pool == r.replica && r.unavailable(ctx, err) {
+		metrics.DBReads.WithLabelValues("primary").Inc()
+		return r.primary.Query(ctx, sql, args...)
+	}
+	return rows, err
+}
+
+// QueryRow falls back to the primary like Query; the error only surfaces
+// when the row is scanned, so that is where the retry happens.
+func (r *ReadRouter) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	pool := r.pick(ctx)
+	row := pool.QueryRow(ctx, sql, args...)
+	if pool != r.replica {
+		return row
+	}
+	return fallbackRow{row: row, retry: func(err error) pgx.Row {
+		if !r.unavailable(ctx, err) {
+			return nil
+		}
+		metrics.DBReads.WithLabelValues("primary").Inc()
+		return r.primary.QueryRow(ctx, sql, args...)
+	}}
+}
+
+// fallbackRow is a replica row that is read again from the primary when
+// retry returns a row for the replica's error.
+type fallbackRow struct {
+	row   pgx.Row
+	retry func(err error) pgx.Row
+}
+
+func (f fallbackRow) Scan(dest ...any) error {
+	err := f.row.Scan(dest...)
+	if err == nil {
+		return nil
+	}
+	if primary := f.retry(err); primary != nil {
+		return primary.Scan(dest...)
+	}
+	return err
+}
+
+// unavailable reports whether err means the replica could not serve the
+// query at all, as opposed to the query failing, and if so marks the replica
+// unhealthy straight away rather than at Monitor's next check.
+func (r *ReadRouter) unavailable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || !replicaUnavailable(err) {
+		return false
+	}
+	if r.healthy.Swap(false) {
+		slog.Warn("read replica unavailable, routing reads to primary", "error", err)
+	}
+	return true
+}
+
+func replicaUnavailable(err error) bool {
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) || pgconn.SafeToRetry(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// connection exceptions, the server shutting down or still starting,
+		// and queries cancelled by a conflict with recovery on the standby
+		return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == "57P01" || pgErr.Code == "57P02" ||
+			pgErr.Code == "57P03" || (pgErr.Code == "40001" && strings.Contains(pgErr.Message, "conflict with recovery"))
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Monitor checks the replica once immediately and then every interval until
+// ctx is cancelled. The replica is used only while it answers and its replay
+// lag is within maxLag.
+func (r *ReadRouter) Monitor(ctx context.Context, interval time.Duration) {
+	if r.replica == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.check(ctx, interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *ReadRouter) check(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// A standby that has replayed everything it received is current however
+	// old its last transaction is; NULLs mean the server is not a standby.
+	var lagSeconds float64
+	err := r.replica.QueryRow(ctx, `
+		SELECT COALESCE(
+			CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE EXTRACT(EPOCH FROM NOW() - pg_last_xact_replay_timestamp())::float8
+			END, 0)
+	`).Scan(&lagSeconds)
+
+	lag := time.Duration(lagSeconds * float64(time.Second))
+	healthy := err == nil && lag <= r.maxLag
+	if was := r.healthy.Swap(healthy); was != healthy {
+		if healthy {
+			slog.Info("read replica healthy, routing reads to it", "lag", lag)
+		} else {
+			slog.Warn("read replica unhealthy, routing reads to primary", "lag", lag, "error", err)
+		}
+	}
+}
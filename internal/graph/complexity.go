@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// listSizeEstimate is the assumed length of lists that take no "first"
+// argument, e.g. an expense's history.
+const listSizeEstimate = 10
+
+// estimateCost bounds the work a query may cause before it runs: every field
+// costs 1, and the selections under a list are paid once per item — "first"
+// items for connections, listSizeEstimate for other lists. Queries that do not
+// parse or name an unknown operation cost 0 and are rejected by the executor.
+func estimateCost(schema *ast.Schema, req Request) int {
+	doc, err := parser.ParseQuery(&ast.Source{Input: req.Query})
+	if err != nil {
+		return 0
+	}
+	var op *ast.OperationDefinition
+	if req.OperationName == "" && len(doc.Operations) == 1 {
+		op = doc.Operations[0]
+	} else {
+		op = doc.Operations.ForName(req.OperationName)
+	}
+	if op == nil {
+		return 0
+	}
+	e := &costEstimator{
+		schema:    schema,
+		fragments: doc.Fragments,
+		variables: req.Variables,
+		visiting:  map[string]bool{},
+	}
+	return e.selections(op.SelectionSet, schema.Query)
+}
+
+type costEstimator struct {
+	schema    *ast.Schema
+	fragments ast.FragmentDefinitionList
+	variables map[string]any
+	// visiting guards against fragment cycles, which the executor rejects
+	// only after this runs
+	visiting map[string]bool
+}
+
+func (e *costEstimator) selections(set ast.SelectionSet, parent *ast.Definition) int {
+	cost := 0
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *ast.Field:
+			cost += e.field(s, parent)
+		case *ast.InlineFragment:
+			cost += e.selections(s.SelectionSet, e.typeOr(s.TypeCondition, parent))
+		case *ast.FragmentSpread:
+			fragment := e.fragments.ForName(s.Name)
+			if fragment == nil || e.visiting[s.Name] {
+				continue
+			}
+			e.visiting[s.Name] = true
+			cost += e.selections(fragment.SelectionSet, e.typeOr(fragment.TypeCondition, parent))
+			delete(e.visiting, s.Name)
+		}
+	}
+	return cost
+}
+
+func (e *costEstimator) field(f *ast.Field, parent *ast.Definition) int {
+	var def *ast.FieldDefinition
+	if parent != nil {
+		def = parent.Fields.ForName(f.Name)
+	}
+	if def == nil {
+		// introspection and unknown fields: count them, but without
+		// type information there is nothing to multiply
+		return 1 + e.selections(f.SelectionSet, nil)
+	}
+
+	children := e.selections(f.SelectionSet, e.schema.Types[def.Type.Name()])
+	multiplier := 1
+	if first := def.Arguments.ForName("first"); first != nil {
+		multiplier = e.intArgument(f, "first", first)
+	} else if def.Type.Elem != nil && !strings.HasSuffix(parent.Name, "Connection") {
+		// a connection's edges were already paid for by its "first"
+		multiplier = listSizeEstimate
+	}
+	return 1 + multiplier*children
+}
+
+// intArgument is the value of an integer argument after variables and
+// defaults are applied.
+func (e *costEstimator) intArgument(f *ast.Field, name string, def *ast.ArgumentDefinition) int {
+	if arg := f.Arguments.ForName(name); arg != nil {
+		if v, err := arg.Value.Value(e.variables); err == nil {
+			switch n := v.(type) {
+			case int64:
+				return max(int(n), 0)
+			case float64:
+				// JSON variables decode as float64
+				return max(int(n), 0)
+			}
+		}
+	}
+	if def.DefaultValue != nil {
+		if v, err := def.DefaultValue.Value(nil); err == nil {
+			if n, ok := v.(int64); ok {
+				return int(n)
+			}
+		}
+	}
+	return listSizeEstimate
+}
+
+func (e *costEstimator) typeOr(name string, fallback *ast.Definition) *ast.Definition {
+	if t, ok := e.schema.Types[name]; ok {
+		return t
+	}
+	return fallback
+}
@@ -0,0 +1,33 @@
+package graph
+
+import (
+	"context"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+)
+
+// graphQLError reports an apperr in a response's errors, with its kind as
+// extensions.code. The message is the client-safe one; causes stay in logs.
+type graphQLError struct {
+	err *apperr.Error
+}
+
+func (e *graphQLError) Error() string {
+	return e.err.Message
+}
+
+func (e *graphQLError) Extensions() map[string]any {
+	extensions := map[string]any{"code": e.err.Kind}
+	if len(e.err.Fields) > 0 {
+		extensions["fields"] = e.err.Fields
+	}
+	return extensions
+}
+
+func resolverError(ctx context.Context, err error) error {
+	appErr := apperr.From(err)
+	if appErr.Kind == apperr.KindInternal {
+		logging.FromContext(ctx).Error("graphql resolver failed", "error", err)
+	}
+	return &graphQLError{err: appErr}
+}
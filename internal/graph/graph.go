@@ -0,0 +1,107 @@
+// Package graph serves a read-only GraphQL view of the authenticated user's
+// expenses. Resolvers go through the same services as the REST handlers;
+// this package only adds query cost limits and per-request batching.
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/services"
+	"fmt"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	gqllog "github.com/graph-gophers/graphql-go/log"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// Options limit what a single query may ask for.
+type Options struct {
+	// MaxDepth is the deepest field nesting allowed.
+	MaxDepth int
+	// MaxComplexity caps the estimated number of fields a query resolves;
+	// see estimateCost.
+	MaxComplexity int
+}
+
+// Request is a GraphQL request as clients POST it.
+type Request struct {
+	Query         string         `json:"query" binding:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Schema executes queries for one user at a time.
+type Schema struct {
+	schema        *graphql.Schema
+	types         *ast.Schema
+	maxComplexity int
+	expenses      *services.ExpenseService
+	audit         *services.AuditService
+}
+
+func NewSchema(expenses *services.ExpenseService, users *services.UserService, audit *services.AuditService, opts Options) (*Schema, error) {
+	resolver := &queryResolver{expenses: expenses, users: users}
+	schema, err := graphql.ParseSchema(schemaSDL, resolver,
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(opts.MaxDepth),
+		graphql.MaxParallelism(100),
+		graphql.Logger(gqllog.LoggerFunc(logPanic)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("parse graphql schema: %w", err)
+	}
+	// the cost estimate needs field types, which graphql-go keeps internal
+	types, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: schemaSDL})
+	if gqlErr != nil {
+		return nil, fmt.Errorf("load graphql schema: %w", gqlErr)
+	}
+	return &Schema{
+		schema:        schema,
+		types:         types,
+		maxComplexity: opts.MaxComplexity,
+		expenses:      expenses,
+		audit:         audit,
+	}, nil
+}
+
+// Exec runs req on behalf of userID. Failures are reported in the response's
+// errors, as GraphQL expects, never as a Go error.
+func (s *Schema) Exec(ctx context.Context, userID int, req Request) *graphql.Response {
+	if cost := estimateCost(s.types, req); cost > s.maxComplexity {
+		err := gqlerrors.Errorf("query is too complex: estimated cost %d exceeds the limit of %d", cost, s.maxComplexity)
+		err.Extensions = map[string]any{"code": "query_too_complex", "cost": cost, "limit": s.maxComplexity}
+		return &graphql.Response{Errors: []*gqlerrors.QueryError{err}}
+	}
+	ctx = withViewer(ctx, &viewer{
+		userID:  userID,
+		loaders: newLoaders(userID, s.expenses, s.audit),
+	})
+	return s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+}
+
+// viewer is the per-request state resolvers share.
+type viewer struct {
+	userID  int
+	loaders *loaders
+}
+
+type viewerKey struct{}
+
+func withViewer(ctx context.Context, v *viewer) context.Context {
+	return context.WithValue(ctx, viewerKey{}, v)
+}
+
+func viewerFrom(ctx context.Context) *viewer {
+	v, _ := ctx.Value(viewerKey{}).(*viewer)
+	return v
+}
+
+func logPanic(ctx context.Context, value any) {
+	logging.FromContext(ctx).Error("panic while resolving graphql query", "panic", value)
+}
@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"context"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/services"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// loaderWait is how long a loader collects keys before it queries; resolvers
+// for sibling list items all ask within it.
+const loaderWait = 2 * time.Millisecond
+
+// loaders batch the per-expense fields, so a page of expenses costs one query
+// per field instead of one per expense. They cache for the request only.
+type loaders struct {
+	history       *dataloader.Loader[int, []*model.AuditEntry]
+	categoryTotal *dataloader.Loader[string, *model.CategoryTotal]
+}
+
+func newLoaders(userID int, expenses *services.ExpenseService, audit *services.AuditService) *loaders {
+	history := func(ctx context.Context, expenseIDs []int) []*dataloader.Result[[]*model.AuditEntry] {
+		results := make([]*dataloader.Result[[]*model.AuditEntry], len(expenseIDs))
+		histories, err := audit.GetExpenseHistoriesService(ctx, expenseIDs, userID)
+		for i, id := range expenseIDs {
+			results[i] = &dataloader.Result[[]*model.AuditEntry]{Data: histories[id], Error: err}
+		}
+		return results
+	}
+
+	categoryTotal := func(ctx context.Context, categories []string) []*dataloader.Result[*model.CategoryTotal] {
+		results := make([]*dataloader.Result[*model.CategoryTotal], len(categories))
+		totals, err := expenses.CategoryTotalsService(ctx, userID, repository.ExpenseFilter{}, categories)
+		byCategory := make(map[string]*model.CategoryTotal, len(totals))
+		for _, total := range totals {
+			byCategory[total.Category] = total
+		}
+		for i, category := range categories {
+			total := byCategory[category]
+			if total == nil {
+				// deleted between listing and loading
+				total = &model.CategoryTotal{Category: category}
+			}
+			results[i] = &dataloader.Result[*model.CategoryTotal]{Data: total, Error: err}
+		}
+		return results
+	}
+
+	return &loaders{
+		history: dataloader.NewBatchedLoader(history,
+			dataloader.WithWait[int, []*model.AuditEntry](loaderWait),
+			dataloader.WithBatchCapacity[int, []*model.AuditEntry](services.MaxExpensePageSize)),
+		categoryTotal: dataloader.NewBatchedLoader(categoryTotal,
+			dataloader.WithWait[string, *model.CategoryTotal](loaderWait)),
+	}
+}
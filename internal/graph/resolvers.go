@@ -0,0 +1,396 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/services"
+	"fmt"
+	"strconv"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+type queryResolver struct {
+	expenses *services.ExpenseService
+	users    *services.UserService
+}
+
+type expenseFilterInput struct {
+	Category  *string
+	MinAmount *float64
+	MaxAmount *float64
+	From      *graphql.Time
+	To        *graphql.Time
+}
+
+func (in *expenseFilterInput) filter() repository.ExpenseFilter {
+	if in == nil {
+		return repository.ExpenseFilter{}
+	}
+	filter := repository.ExpenseFilter{
+		Category:  in.Category,
+		MinAmount: in.MinAmount,
+		MaxAmount: in.MaxAmount,
+	}
+	if in.From != nil {
+		filter.From = &in.From.Time
+	}
+	if in.To != nil {
+		filter.To = &in.To.Time
+	}
+	return filter
+}
+
+func (r *queryResolver) Me(ctx context.Context) (*userResolver, error) {
+	user, err := r.users.GetUserService(ctx, viewerFrom(ctx).userID)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return &userResolver{user: user}, nil
+}
+
+func (r *queryResolver) Expense(ctx context.Context, args struct{ ID graphql.ID }) (*expenseResolver, error) {
+	expenseID, err := strconv.Atoi(string(args.ID))
+	if err != nil {
+		return nil, resolverError(ctx, apperr.Validation("invalid expense id", apperr.Field("id", "must be an integer")))
+	}
+	expense, err := r.expenses.GetExpenseByIDService(ctx, expenseID, viewerFrom(ctx).userID)
+	if err != nil {
+		if errors.Is(err, services.ErrExpenseNotFound) {
+			return nil, nil
+		}
+		return nil, resolverError(ctx, err)
+	}
+	return &expenseResolver{expense: expense}, nil
+}
+
+func (r *queryResolver) Expenses(ctx context.Context, args struct {
+	Filter *expenseFilterInput
+	First  int32
+	After  *string
+}) (*expenseConnectionResolver, error) {
+	first := int(args.First)
+	var after *repository.ExpenseCursor
+	if args.After != nil {
+		cursor, err := decodeCursor(*args.After)
+		if err != nil {
+			return nil, resolverError(ctx, apperr.Validation("invalid cursor", apperr.Field("after", "is not a cursor from this API")))
+		}
+		after = cursor
+	}
+
+	filter := args.Filter.filter()
+	userID := viewerFrom(ctx).userID
+	page := &services.ExpensePage{}
+	// first: 0 is a valid way to ask for totalCount only
+	if first != 0 {
+		var err error
+		page, err = r.expenses.ListExpensesService(ctx, userID, filter, after, first)
+		if err != nil {
+			return nil, resolverError(ctx, err)
+		}
+	}
+	return &expenseConnectionResolver{page: page, filter: filter, service: r.expenses}, nil
+}
+
+func (r *queryResolver) Categories(ctx context.Context, args struct{ Filter *expenseFilterInput }) ([]*categoryTotalResolver, error) {
+	return categoryTotals(ctx, r.expenses, args.Filter.filter())
+}
+
+func (r *queryResolver) MonthlyTotals(ctx context.Context, args struct{ Filter *expenseFilterInput }) ([]*monthlyTotalResolver, error) {
+	return monthlyTotals(ctx, r.expenses, args.Filter.filter())
+}
+
+func (r *queryResolver) Summary(ctx context.Context, args struct{ Filter *expenseFilterInput }) (*summaryResolver, error) {
+	filter := args.Filter.filter()
+	summary, err := r.expenses.SummaryService(ctx, viewerFrom(ctx).userID, filter)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return &summaryResolver{summary: summary, filter: filter, service: r.expenses}, nil
+}
+
+func categoryTotals(ctx context.Context, service *services.ExpenseService, filter repository.ExpenseFilter) ([]*categoryTotalResolver, error) {
+	totals, err := service.CategoryTotalsService(ctx, viewerFrom(ctx).userID, filter, nil)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	resolvers := make([]*categoryTotalResolver, len(totals))
+	for i, total := range totals {
+		resolvers[i] = &categoryTotalResolver{total: total}
+	}
+	return resolvers, nil
+}
+
+func monthlyTotals(ctx context.Context, service *services.ExpenseService, filter repository.ExpenseFilter) ([]*monthlyTotalResolver, error) {
+	totals, err := service.MonthlyTotalsService(ctx, viewerFrom(ctx).userID, filter)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	resolvers := make([]*monthlyTotalResolver, len(totals))
+	for i, total := range totals {
+		resolvers[i] = &monthlyTotalResolver{total: total}
+	}
+	return resolvers, nil
+}
+
+type userResolver struct {
+	user *model.User
+}
+
+func (r *userResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatInt(r.user.ID, 10))
+}
+
+func (r *userResolver) Email() string {
+	return r.user.Email
+}
+
+func (r *userResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.user.CreatedAt}
+}
+
+type expenseResolver struct {
+	expense *model.Expense
+}
+
+func (r *expenseResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(r.expense.ID))
+}
+
+func (r *expenseResolver) Amount() float64 {
+	return r.expense.Amount
+}
+
+func (r *expenseResolver) Category() string {
+	return r.expense.Category
+}
+
+func (r *expenseResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.expense.CreatedAt}
+}
+
+func (r *expenseResolver) Version() int32 {
+	return int32(r.expense.Version)
+}
+
+func (r *expenseResolver) CategoryTotal(ctx context.Context) (*categoryTotalResolver, error) {
+	total, err := viewerFrom(ctx).loaders.categoryTotal.Load(ctx, r.expense.Category)()
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	return &categoryTotalResolver{total: total}, nil
+}
+
+func (r *expenseResolver) History(ctx context.Context) ([]*auditEntryResolver, error) {
+	entries, err := viewerFrom(ctx).loaders.history.Load(ctx, r.expense.ID)()
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+	resolvers := make([]*auditEntryResolver, len(entries))
+	for i, entry := range entries {
+		resolvers[i] = &auditEntryResolver{entry: entry}
+	}
+	return resolvers, nil
+}
+
+type auditEntryResolver struct {
+	entry *model.AuditEntry
+}
+
+func (r *auditEntryResolver) ID() graphql.ID {
+	return graphql.ID(strconv.FormatInt(r.entry.ID, 10))
+}
+
+func (r *auditEntryResolver) Action() string {
+	return r.entry.Action
+}
+
+func (r *auditEntryResolver) ActorID() *graphql.ID {
+	if r.entry.ActorID == nil {
+		return nil
+	}
+	id := graphql.ID(strconv.Itoa(*r.entry.ActorID))
+	return &id
+}
+
+func (r *auditEntryResolver) Before() *string {
+	return rawJSON(r.entry.Before)
+}
+
+func (r *auditEntryResolver) After() *string {
+	return rawJSON(r.entry.After)
+}
+
+func (r *auditEntryResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.entry.CreatedAt}
+}
+
+func rawJSON(raw []byte) *string {
+	if len(raw) == 0 {
+		return nil
+	}
+	s := string(raw)
+	return &s
+}
+
+type expenseConnectionResolver struct {
+	page    *services.ExpensePage
+	filter  repository.ExpenseFilter
+	service *services.ExpenseService
+}
+
+func (r *expenseConnectionResolver) Edges() []*expenseEdgeResolver {
+	edges := make([]*expenseEdgeResolver, len(r.page.Expenses))
+	for i, expense := range r.page.Expenses {
+		edges[i] = &expenseEdgeResolver{expense: expense}
+	}
+	return edges
+}
+
+func (r *expenseConnectionResolver) PageInfo() *pageInfoResolver {
+	return &pageInfoResolver{page: r.page}
+}
+
+func (r *expenseConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := r.service.CountExpensesService(ctx, viewerFrom(ctx).userID, r.filter)
+	if err != nil {
+		return 0, resolverError(ctx, err)
+	}
+	return int32(count), nil
+}
+
+type expenseEdgeResolver struct {
+	expense *model.Expense
+}
+
+func (r *expenseEdgeResolver) Cursor() string {
+	return encodeCursor(r.expense)
+}
+
+func (r *expenseEdgeResolver) Node() *expenseResolver {
+	return &expenseResolver{expense: r.expense}
+}
+
+type pageInfoResolver struct {
+	page *services.ExpensePage
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.page.HasNextPage
+}
+
+func (r *pageInfoResolver) HasPreviousPage() bool {
+	return false
+}
+
+func (r *pageInfoResolver) StartCursor() *string {
+	if len(r.page.Expenses) == 0 {
+		return nil
+	}
+	cursor := encodeCursor(r.page.Expenses[0])
+	return &cursor
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	if len(r.page.Expenses) == 0 {
+		return nil
+	}
+	cursor := encodeCursor(r.page.Expenses[len(r.page.Expenses)-1])
+	return &cursor
+}
+
+type categoryTotalResolver struct {
+	total *model.CategoryTotal
+}
+
+func (r *categoryTotalResolver) Category() string {
+	return r.total.Category
+}
+
+func (r *categoryTotalResolver) Count() int32 {
+	return int32(r.total.Count)
+}
+
+func (r *categoryTotalResolver) Total() float64 {
+	return r.total.Total
+}
+
+type monthlyTotalResolver struct {
+	total *model.MonthlyTotal
+}
+
+func (r *monthlyTotalResolver) Month() string {
+	return r.total.Month.UTC().Format("2006-01")
+}
+
+func (r *monthlyTotalResolver) Count() int32 {
+	return int32(r.total.Count)
+}
+
+func (r *monthlyTotalResolver) Total() float64 {
+	return r.total.Total
+}
+
+type summaryResolver struct {
+	summary *model.ExpenseSummary
+	filter  repository.ExpenseFilter
+	service *services.ExpenseService
+}
+
+func (r *summaryResolver) Count() int32 {
+	return int32(r.summary.Count)
+}
+
+func (r *summaryResolver) Total() float64 {
+	return r.summary.Total
+}
+
+func (r *summaryResolver) Average() *float64 {
+	if r.summary.Count == 0 {
+		return nil
+	}
+	average := r.summary.Total / float64(r.summary.Count)
+	return &average
+}
+
+func (r *summaryResolver) Min() *float64 {
+	return r.summary.Min
+}
+
+func (r *summaryResolver) Max() *float64 {
+	return r.summary.Max
+}
+
+func (r *summaryResolver) ByCategory(ctx context.Context) ([]*categoryTotalResolver, error) {
+	return categoryTotals(ctx, r.service, r.filter)
+}
+
+func (r *summaryResolver) ByMonth(ctx context.Context) ([]*monthlyTotalResolver, error) {
+	return monthlyTotals(ctx, r.service, r.filter)
+}
+
+// A cursor is the position of an expense in newest-first order. Clients
+// treat it as opaque.
+func encodeCursor(expense *model.Expense) string {
+	raw := fmt.Sprintf("%d:%d", expense.CreatedAt.UnixNano(), expense.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (*repository.ExpenseCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var nanos int64
+	var id int
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &nanos, &id); err != nil {
+		return nil, err
+	}
+	return &repository.ExpenseCursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
@@ -0,0 +1,139 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/services"
+	"expense-tracker/internal/utils"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys a caller authenticates with.
+const (
+	apiKeyKey = "x-api-key"
+	// userIDKey names the user a service acts for with an API key.
+	userIDKey = "x-user-id"
+)
+
+type apiKey struct {
+	key     []byte
+	service string
+}
+
+// authenticator accepts the same bearer tokens as AuthMiddleware, or a
+// service API key plus the user to act for, who must exist.
+type authenticator struct {
+	tokens  *utils.TokenManager
+	users   *services.UserService
+	apiKeys []apiKey
+}
+
+func newAuthenticator(tokens *utils.TokenManager, users *services.UserService, keys map[string]string) *authenticator {
+	a := &authenticator{tokens: tokens, users: users}
+	for key, service := range keys {
+		a.apiKeys = append(a.apiKeys, apiKey{key: []byte(key), service: service})
+	}
+	return a
+}
+
+func (a *authenticator) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if isPublic(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	ctx, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if isPublic(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	ctx, err := a.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+func (a *authenticator) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if key := firstValue(md, apiKeyKey); key != "" {
+		service, ok := a.service(key)
+		if !ok {
+			return nil, apperr.Unauthorized("invalid api key")
+		}
+		userID, err := strconv.Atoi(firstValue(md, userIDKey))
+		if err != nil || userID <= 0 {
+			return nil, apperr.Unauthorized("api key calls must name the user to act for in x-user-id")
+		}
+		// a token proves its user existed; a bare id proves nothing
+		if _, err := a.users.GetUserService(ctx, userID); err != nil {
+			if errors.Is(err, services.ErrUserNotFound) {
+				return nil, apperr.Unauthorized("x-user-id names no user")
+			}
+			return nil, err
+		}
+		ctx = logging.With(ctx, "user_id", userID, "service", service)
+		setCallLogger(ctx)
+		return withUserID(ctx, userID), nil
+	}
+
+	authHeader := firstValue(md, "authorization")
+	if authHeader == "" {
+		return nil, apperr.Unauthorized("missing token")
+	}
+	tokenParts := strings.Fields(authHeader)
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		return nil, apperr.Unauthorized("invalid token format")
+	}
+	userID, err := a.tokens.Authenticate(tokenParts[1])
+	if err != nil {
+		return nil, err
+	}
+	ctx = logging.With(ctx, "user_id", userID)
+	setCallLogger(ctx)
+	return withUserID(ctx, userID), nil
+}
+
+// service finds the service owning key, comparing against every key in
+// constant time.
+func (a *authenticator) service(key string) (string, bool) {
+	var service string
+	found := false
+	for _, k := range a.apiKeys {
+		if subtle.ConstantTimeCompare(k.key, []byte(key)) == 1 {
+			service, found = k.service, true
+		}
+	}
+	return service, found
+}
+
+// isPublic reports whether method is served without authentication: health
+// checks and reflection.
+func isPublic(method string) bool {
+	return strings.HasPrefix(method, "/grpc.health.v1.Health/") || strings.HasPrefix(method, "/grpc.reflection.")
+}
+
+type userIDKeyType struct{}
+
+func withUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDKeyType{}, userID)
+}
+
+// userIDFrom returns the authenticated user. Every non-public method runs
+// after authenticate, so it is always set there.
+func userIDFrom(ctx context.Context) int {
+	userID, _ := ctx.Value(userIDKeyType{}).(int)
+	return userID
+}
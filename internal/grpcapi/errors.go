@@ -0,0 +1,62 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var grpcCodes = map[apperr.Kind]codes.Code{
+	apperr.KindValidation:           codes.InvalidArgument,
+	apperr.KindNotFound:             codes.NotFound,
+	apperr.KindConflict:             codes.AlreadyExists,
+	apperr.KindUnauthorized:         codes.Unauthenticated,
+	apperr.KindForbidden:            codes.PermissionDenied,
+	apperr.KindPreconditionFailed:   codes.FailedPrecondition,
+	apperr.KindPreconditionRequired: codes.FailedPrecondition,
+	apperr.KindUnsupportedMediaType: codes.InvalidArgument,
+	apperr.KindPayloadTooLarge:      codes.ResourceExhausted,
+	apperr.KindUnprocessable:        codes.FailedPrecondition,
+	apperr.KindTooManyRequests:      codes.ResourceExhausted,
+	apperr.KindUnavailable:          codes.Unavailable,
+	apperr.KindInternal:             codes.Internal,
+}
+
+// toStatus renders err as a gRPC status the way the error middleware renders
+// problem details: the client-safe message, invalid fields as a BadRequest
+// detail, and internal causes only in the log. Statuses from gRPC itself,
+// e.g. a failed Send to a client that went away, pass through.
+func toStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, "the call was cancelled")
+	}
+
+	appErr := apperr.From(err)
+	code, ok := grpcCodes[appErr.Kind]
+	if !ok {
+		code = codes.Internal
+	}
+	if code == codes.Internal {
+		logging.FromContext(ctx).Error("rpc failed", "error", err)
+	}
+
+	st := status.New(code, appErr.Message)
+	if len(appErr.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(appErr.Fields))
+		for i, f := range appErr.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message}
+		}
+		if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
@@ -0,0 +1,190 @@
+package grpcapi
+
+import (
+	"context"
+	"expense-tracker/internal/apperr"
+	pb "expense-tracker/internal/gen/expensetracker/v1"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/services"
+	"math"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type expenseServer struct {
+	pb.UnimplementedExpenseServiceServer
+	expenses *services.ExpenseService
+}
+
+func (s *expenseServer) CreateExpense(ctx context.Context, req *pb.CreateExpenseRequest) (*pb.CreateExpenseResponse, error) {
+	expense, err := s.expenses.AddExpenseService(ctx, userIDFrom(ctx), req.GetAmount(), req.GetCategory(), "")
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateExpenseResponse{Expense: expenseToProto(expense)}, nil
+}
+
+func (s *expenseServer) GetExpense(ctx context.Context, req *pb.GetExpenseRequest) (*pb.GetExpenseResponse, error) {
+	expenseID, err := expenseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	expense, err := s.expenses.GetExpenseByIDService(ctx, expenseID, userIDFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetExpenseResponse{Expense: expenseToProto(expense)}, nil
+}
+
+func (s *expenseServer) UpdateExpense(ctx context.Context, req *pb.UpdateExpenseRequest) (*pb.UpdateExpenseResponse, error) {
+	expenseID, err := expenseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if req.Amount == nil && req.Category == nil {
+		return nil, apperr.Validation("nothing to update", apperr.Field("amount", "set amount, category or both"))
+	}
+	expectedVersion, err := requireVersion(req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	expense, err := s.expenses.UpdateExpenseService(ctx, expenseID, userIDFrom(ctx), services.UpdateExpenseInput{
+		Amount:           req.Amount,
+		Category:         req.Category,
+		ExpectedVersions: expectedVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateExpenseResponse{Expense: expenseToProto(expense)}, nil
+}
+
+func (s *expenseServer) DeleteExpense(ctx context.Context, req *pb.DeleteExpenseRequest) (*pb.DeleteExpenseResponse, error) {
+	expenseID, err := expenseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	expectedVersion, err := requireVersion(req.ExpectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.expenses.DeleteExpenseService(ctx, expenseID, userIDFrom(ctx), expectedVersion); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteExpenseResponse{}, nil
+}
+
+// ListExpenses walks the listing page by page, so a long stream holds no
+// more than one page in memory and no transaction open between pages.
+func (s *expenseServer) ListExpenses(req *pb.ListExpensesRequest, stream grpc.ServerStreamingServer[pb.ListExpensesResponse]) error {
+	ctx := stream.Context()
+	if req.GetLimit() < 0 {
+		return apperr.Validation("invalid limit", apperr.Field("limit", "must not be negative"))
+	}
+	filter := filterFromProto(req.GetFilter())
+	userID := userIDFrom(ctx)
+
+	remaining := int(req.GetLimit())
+	var after *repository.ExpenseCursor
+	for {
+		pageSize := services.MaxExpensePageSize
+		if req.GetLimit() > 0 {
+			pageSize = min(pageSize, remaining)
+		}
+		page, err := s.expenses.ListExpensesService(ctx, userID, filter, after, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, expense := range page.Expenses {
+			if err := stream.Send(&pb.ListExpensesResponse{Expense: expenseToProto(expense)}); err != nil {
+				return err
+			}
+		}
+		remaining -= len(page.Expenses)
+		if !page.HasNextPage || (req.GetLimit() > 0 && remaining <= 0) {
+			return nil
+		}
+		last := page.Expenses[len(page.Expenses)-1]
+		after = &repository.ExpenseCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+func (s *expenseServer) GetSummary(ctx context.Context, req *pb.GetSummaryRequest) (*pb.GetSummaryResponse, error) {
+	filter := filterFromProto(req.GetFilter())
+	userID := userIDFrom(ctx)
+
+	summary, err := s.expenses.SummaryService(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.expenses.CategoryTotalsService(ctx, userID, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetSummaryResponse{
+		Count: int64(summary.Count),
+		Total: summary.Total,
+		Min:   summary.Min,
+		Max:   summary.Max,
+	}
+	for _, total := range totals {
+		resp.ByCategory = append(resp.ByCategory, &pb.CategoryTotal{
+			Category: total.Category,
+			Count:    int64(total.Count),
+			Total:    total.Total,
+		})
+	}
+	return resp, nil
+}
+
+func expenseToProto(expense *model.Expense) *pb.Expense {
+	return &pb.Expense{
+		Id:        int64(expense.ID),
+		Amount:    expense.Amount,
+		Category:  expense.Category,
+		CreatedAt: timestamppb.New(expense.CreatedAt),
+		Version:   int64(expense.Version),
+	}
+}
+
+func filterFromProto(in *pb.ExpenseFilter) repository.ExpenseFilter {
+	if in == nil {
+		return repository.ExpenseFilter{}
+	}
+	filter := repository.ExpenseFilter{
+		Category:  in.Category,
+		MinAmount: in.MinAmount,
+		MaxAmount: in.MaxAmount,
+	}
+	if in.From != nil {
+		from := in.From.AsTime()
+		filter.From = &from
+	}
+	if in.To != nil {
+		to := in.To.AsTime()
+		filter.To = &to
+	}
+	return filter
+}
+
+// expenseID narrows a wire id; the service rejects anything not positive.
+func expenseID(id int64) (int, error) {
+	if id > math.MaxInt32 {
+		return 0, services.ErrExpenseNotFound
+	}
+	return int(id), nil
+}
+
+// errVersionRequired is the gRPC counterpart of a missing If-Match: writes
+// must say which version they were based on.
+var errVersionRequired = apperr.New(apperr.KindPreconditionRequired, "expected_version is required")
+
+func requireVersion(v *int64) (services.Versions, error) {
+	if v == nil {
+		return nil, errVersionRequired
+	}
+	return services.Versions{int(*v)}, nil
+}
@@ -0,0 +1,201 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/db"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/reqctx"
+	"expense-tracker/internal/tracing"
+	"fmt"
+	"log/slog"
+	"net"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey is the metadata counterpart of the X-Request-ID header.
+const requestIDKey = "x-request-id"
+
+// withRequestContext does for a call what middleware.RequestContext does for
+// an HTTP request: an ID (the caller's x-request-id when valid), request
+// metadata, and a logger carrying both. "x-read-consistency: strong" pins
+// the call's reads to the primary.
+func withRequestContext(ctx context.Context, method string) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := firstValue(md, requestIDKey)
+	if !validRequestID(requestID) {
+		requestID = newRequestID()
+	}
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	ctx = reqctx.WithMeta(ctx, reqctx.Meta{RequestID: requestID, IP: ip})
+	logger := logging.FromContext(ctx).With("request_id", requestID, "rpc", method)
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		logger = logger.With("trace_id", traceID)
+	}
+	ctx = logging.WithLogger(ctx, logger)
+	ctx = context.WithValue(ctx, callKey{}, &call{logger: logger})
+	if strings.EqualFold(firstValue(md, "x-read-consistency"), "strong") {
+		ctx = db.WithPrimary(ctx)
+	}
+	return ctx, requestID
+}
+
+// call lets interceptors further in enrich the access log line, which is
+// written with the outer context.
+type call struct {
+	logger *slog.Logger
+}
+
+type callKey struct{}
+
+// setCallLogger makes the logger in ctx, e.g. one carrying user_id, the one
+// the call's access log line is written with.
+func setCallLogger(ctx context.Context) {
+	if c, ok := ctx.Value(callKey{}).(*call); ok {
+		c.logger = logging.FromContext(ctx)
+	}
+}
+
+// logCall writes one access log line per call, at a level matching the code.
+func logCall(ctx context.Context, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK, codes.Canceled:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded, codes.Unimplemented:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	logger := logging.FromContext(ctx)
+	if c, ok := ctx.Value(callKey{}).(*call); ok {
+		logger = c.logger
+	}
+	logger.LogAttrs(ctx, level, "rpc completed",
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+	)
+}
+
+func requestContextUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx, requestID := withRequestContext(ctx, info.FullMethod)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+
+	resp, err := handler(ctx, req)
+	logCall(ctx, start, err)
+	return resp, err
+}
+
+func requestContextStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, requestID := withRequestContext(ss.Context(), info.FullMethod)
+	_ = ss.SetHeader(metadata.Pairs(requestIDKey, requestID))
+
+	err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	logCall(ctx, start, err)
+	return err
+}
+
+func errorsUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return resp, nil
+}
+
+func errorsStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := handler(srv, ss); err != nil {
+		return toStatus(ss.Context(), err)
+	}
+	return nil
+}
+
+func recoveryUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = recoveredError(ctx, recovered)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func recoveryStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = recoveredError(ss.Context(), recovered)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func recoveredError(ctx context.Context, recovered any) error {
+	err := fmt.Errorf("panic: %v", recovered)
+	logging.FromContext(ctx).Error("rpc panicked", "error", err, "stack", string(debug.Stack()))
+	return apperr.Internal(err)
+}
+
+// timeoutUnary bounds unary calls like the HTTP request timeout. Streams
+// are left unbounded; they end when the client goes away.
+func timeoutUnary(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// contextStream swaps the context a stream handler sees.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// validRequestID accepts caller-supplied IDs that are short and printable.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,90 @@
+// Package grpcapi serves the gRPC API used by internal services. It sits
+// next to the HTTP router: the same services do the work, and callers
+// authenticate with the same JWTs or with a service API key.
+package grpcapi
+
+import (
+	"context"
+	"expense-tracker/internal/config"
+	pb "expense-tracker/internal/gen/expensetracker/v1"
+	"expense-tracker/internal/services"
+	"expense-tracker/internal/utils"
+	"net"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Deps are what the RPCs dispatch to.
+type Deps struct {
+	Users    *services.UserService
+	Expenses *services.ExpenseService
+	Tokens   *utils.TokenManager
+}
+
+type Server struct {
+	server *grpc.Server
+	health *health.Server
+}
+
+// New builds the gRPC server. It fails on invalid API keys.
+func New(cfg *config.Config, deps Deps) (*Server, error) {
+	apiKeys, err := cfg.GRPC.APIKeySet()
+	if err != nil {
+		return nil, err
+	}
+	auth := newAuthenticator(deps.Tokens, deps.Users, apiKeys)
+
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			requestContextUnary,
+			errorsUnary,
+			recoveryUnary,
+			auth.unary,
+			timeoutUnary(cfg.Server.RequestTimeout),
+		),
+		grpc.ChainStreamInterceptor(
+			requestContextStream,
+			errorsStream,
+			recoveryStream,
+			auth.stream,
+		),
+	)
+	pb.RegisterUserServiceServer(server, &userServer{users: deps.Users})
+	pb.RegisterExpenseServiceServer(server, &expenseServer{expenses: deps.Expenses})
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	if cfg.GRPC.Reflection {
+		reflection.Register(server)
+	}
+	return &Server{server: server, health: healthServer}, nil
+}
+
+// Serve accepts connections on lis until Shutdown.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Shutdown reports NOT_SERVING to health checks and waits for in-flight
+// calls, including open streams, to finish. Calls still running when ctx
+// ends are cancelled.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.server.Stop()
+		<-done
+	}
+}
@@ -0,0 +1,26 @@
+package grpcapi
+
+import (
+	"context"
+	pb "expense-tracker/internal/gen/expensetracker/v1"
+	"expense-tracker/internal/services"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	users *services.UserService
+}
+
+func (s *userServer) GetMe(ctx context.Context, req *pb.GetMeRequest) (*pb.GetMeResponse, error) {
+	user, err := s.users.GetUserService(ctx, userIDFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetMeResponse{User: &pb.User{
+		Id:        user.ID,
+		Email:     user.Email,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+	}}, nil
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+func (h *AuditHandler) GetExpenseHistoryHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("get expense history failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	idStr := c.Param("id")
+	expenseID, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("invalid expense id", "user_id", id)
+		c.Error(apperr.Validation("invalid expense id", apperr.Field("id", "must be an integer")))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	entries, err := h.auditService.GetExpenseHistoryService(ctx, expenseID, id)
+	if err != nil {
+		logger.Warn("failed to fetch expense history", "user_id", id, "expenseID", expenseID, "error", err)
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"history": entries,
+	})
+}
+
+// GetActivityHandler serves the user's activity feed. Pages are requested with
+// ?limit= and ?before=<next_before of the previous page>.
+func (h *AuditHandler) GetActivityHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("get activity failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.Error(apperr.Validation("invalid limit", apperr.Field("limit", "must be an integer")))
+		return
+	}
+	before, err := strconv.ParseInt(c.DefaultQuery("before", "0"), 10, 64)
+	if err != nil {
+		c.Error(apperr.Validation("invalid before", apperr.Field("before", "must be an integer")))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	entries, err := h.auditService.GetActivityService(ctx, id, before, limit)
+	if err != nil {
+		logger.Error("failed to fetch activity", "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+
+	response := gin.H{"activity": entries}
+	if len(entries) > 0 {
+		response["next_before"] = entries[len(entries)-1].ID
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// report JSON names rather than Go field names in validation errors
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+	}
+}
+
+// bindError turns a ShouldBindJSON failure into a validation error that names
+// the offending fields.
+func bindError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return apperr.New(apperr.KindPayloadTooLarge, "request body too large")
+	}
+
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		fields := make([]apperr.FieldError, len(invalid))
+		for i, fe := range invalid {
+			fields[i] = apperr.Field(fieldPath(fe), fieldMessage(fe))
+		}
+		return apperr.Validation("request body failed validation", fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return apperr.Validation("request body failed validation", apperr.Field(typeErr.Field, "must be of type "+typeErr.Type.String()))
+	}
+	return apperr.Validation("request body is not valid JSON")
+}
+
+// fieldPath drops the root struct name: "ExpenseRequest.amount" -> "amount".
+func fieldPath(fe validator.FieldError) string {
+	_, path, ok := strings.Cut(fe.Namespace(), ".")
+	if !ok {
+		return fe.Field()
+	}
+	return path
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min", "max":
+		bound := "at least"
+		if fe.Tag() == "max" {
+			bound = "at most"
+		}
+		switch fe.Kind() {
+		case reflect.String:
+			return "must be " + bound + " " + fe.Param() + " characters long"
+		case reflect.Slice, reflect.Map:
+			return "must have " + bound + " " + fe.Param() + " items"
+		}
+		return "must be " + bound + " " + fe.Param()
+	}
+	return "is invalid"
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BudgetRequest struct {
+	MonthlyLimit float64 `json:"monthly_limit" binding:"required"`
+}
+
+type BudgetHandler struct {
+	budgetService *services.BudgetService
+}
+
+func NewBudgetHandler(budgetService *services.BudgetService) *BudgetHandler {
+	return &BudgetHandler{budgetService: budgetService}
+}
+
+func (h *BudgetHandler) ListBudgetsHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("list budgets failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	budgets, err := h.budgetService.ListBudgetsService(ctx, id)
+	if err != nil {
+		logger.Error("failed to fetch budgets", "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"budgets": budgets,
+	})
+}
+
+// SetBudgetHandler creates or replaces the monthly limit of the category in
+// the path.
+func (h *BudgetHandler) SetBudgetHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("set budget failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	var input BudgetRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Warn("set budget failed: invalid input", "error", err)
+		c.Error(bindError(err))
+		return
+	}
+
+	category := c.Param("category")
+	ctx := c.Request.Context()
+
+	// call service
+
+	budget, err := h.budgetService.SetBudgetService(ctx, id, category, input.MonthlyLimit)
+	if err != nil {
+		logger.Warn("set budget failed", "user_id", id, "category", category, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("budget set", "user_id", id, "category", category)
+	c.JSON(http.StatusOK, budget)
+}
+
+func (h *BudgetHandler) DeleteBudgetHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("delete budget failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	category := c.Param("category")
+	ctx := c.Request.Context()
+
+	// call service
+
+	if err := h.budgetService.DeleteBudgetService(ctx, id, category); err != nil {
+		logger.Warn("failed to delete budget", "user_id", id, "category", category, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("budget deleted", "user_id", id, "category", category)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "budget deleted",
+	})
+}
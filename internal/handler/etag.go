@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/services"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errIfMatchMissing = apperr.New(apperr.KindPreconditionRequired, "If-Match header is required")
+	errIfMatchInvalid = apperr.Validation("If-Match must be an ETag returned by this API or *")
+)
+
+// expenseETag renders an expense version as a strong entity tag.
+func expenseETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// entityTags splits a header line into its comma-separated entity-tags, weak
+// ones keeping their W/ prefix. It stops at the first element that is not an
+// entity-tag and reports whether the whole line was well formed.
+func entityTags(header string) ([]string, bool) {
+	var tags []string
+	for rest := header; ; {
+		rest = strings.TrimLeft(rest, " \t,")
+		if rest == "" {
+			return tags, true
+		}
+		weak := strings.HasPrefix(rest, "W/")
+		opaque := strings.TrimPrefix(rest, "W/")
+		if !strings.HasPrefix(opaque, `"`) {
+			return tags, false
+		}
+		end := strings.IndexByte(opaque[1:], '"')
+		if end < 0 {
+			return tags, false
+		}
+		tag := opaque[:end+2]
+		rest = opaque[end+2:]
+		if weak {
+			tag = "W/" + tag
+		}
+		tags = append(tags, tag)
+	}
+}
+
+// noneMatch reports whether If-None-Match matches etag, comparing weakly as
+// RFC 9110 requires: "*" matches, and so does etag in a comma-separated list,
+// weak or not, across however many header lines the client sent.
+func noneMatch(c *gin.Context, etag string) bool {
+	for _, header := range c.Request.Header.Values("If-None-Match") {
+		if strings.TrimSpace(header) == "*" {
+			return true
+		}
+		// a malformed element ends the line; the tags before it still count
+		tags, _ := entityTags(header)
+		for _, tag := range tags {
+			if strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ifMatchVersions reads the expense versions the client expects from
+// If-Match, a "*" or a comma-separated list of entity-tags. A "*" matches any
+// current version and yields nil. Weak tags never match, as If-Match requires
+// strong comparison; a list of nothing but weak tags fails straight away.
+func ifMatchVersions(c *gin.Context) (services.Versions, error) {
+	headers := c.Request.Header.Values("If-Match")
+	joined := strings.TrimSpace(strings.Join(headers, ","))
+	switch {
+	case joined == "":
+		return nil, errIfMatchMissing
+	case joined == "*":
+		return nil, nil
+	}
+
+	tags, ok := entityTags(joined)
+	if !ok || len(tags) == 0 {
+		return nil, errIfMatchInvalid
+	}
+	versions := services.Versions{}
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		digits := tag[1 : len(tag)-1]
+		if digits == "" || strings.Trim(digits, "0123456789") != "" {
+			return nil, errIfMatchInvalid
+		}
+		version, err := strconv.Atoi(digits)
+		if err != nil {
+			return nil, errIfMatchInvalid
+		}
+		versions = append(versions, version)
+	}
+	if len(versions) == 0 {
+		return nil, services.ErrVersionMismatch
+	}
+	return versions, nil
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BatchFilterRequest struct {
+	Category  *string    `json:"category"`
+	MinAmount *float64   `json:"min_amount"`
+	MaxAmount *float64   `json:"max_amount"`
+	From      *time.Time `json:"from"`
+	To        *time.Time `json:"to"`
+}
+
+type BatchOperationRequest struct {
+	Op           string              `json:"op" binding:"required,oneof=create update delete recategorize"`
+	ID           int                 `json:"id"`
+	Amount       *float64            `json:"amount"`
+	Category     *string             `json:"category"`
+	Description  *string             `json:"description"`
+	Tags         *[]string           `json:"tags"`
+	Reimbursable *bool               `json:"reimbursable"`
+	Filter       *BatchFilterRequest `json:"filter"`
+	Version      *int                `json:"version"`
+}
+
+type BatchExpenseRequest struct {
+	Operations []BatchOperationRequest `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+func (h *ExpenseHandler) BatchExpenseHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("batch expenses failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	var input BatchExpenseRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Warn("batch expenses failed: invalid input", "user_id", id, "error", err)
+		c.Error(bindError(err))
+		return
+	}
+
+	// Map handler structs to service structs
+	ops := make([]services.BatchOperation, len(input.Operations))
+	for i, op := range input.Operations {
+		ops[i] = services.BatchOperation{
+			Op:              services.BatchOpType(op.Op),
+			ExpenseID:       op.ID,
+			Amount:          op.Amount,
+			Category:        op.Category,
+			Description:     op.Description,
+			Tags:            op.Tags,
+			Reimbursable:    op.Reimbursable,
+			ExpectedVersion: op.Version,
+		}
+		if op.Filter != nil {
+			ops[i].Filter = &repository.ExpenseFilter{
+				Category:  op.Filter.Category,
+				MinAmount: op.Filter.MinAmount,
+				MaxAmount: op.Filter.MaxAmount,
+				From:      op.Filter.From,
+				To:        op.Filter.To,
+			}
+		}
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	results, err := h.expenseService.BatchExpenseService(ctx, id, ops)
+	if err != nil {
+		var batchErr *services.BatchError
+		if !errors.As(err, &batchErr) {
+			logger.Warn("batch expenses failed", "user_id", id, "error", err)
+			c.Error(err)
+			return
+		}
+		// the problem carries every operation's outcome so clients can see which one failed
+		logger.Warn("batch expenses rolled back", "user_id", id, "error", err)
+		c.Error(apperr.From(err).With("results", batchErr.Results))
+		return
+	}
+	logger.Info("batch expenses applied", "user_id", id, "operations", len(results))
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+	})
+}
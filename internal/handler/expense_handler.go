@@ -1,27 +1,32 @@
 package handler
 
 import (
-	"context"
-	"errors"
+	"encoding/json"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
 	"expense-tracker/internal/services"
-	"expense-tracker/internal/utils"
 	"fmt"
-	"log/slog"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ExpenseRequest may leave out the category when a rule sets one.
 type ExpenseRequest struct {
-	Amount   float64 `json:"amount" binding:"required"`
-	Category string  `json:"category" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required"`
+	Category    string  `json:"category"`
+	Description string  `json:"description"`
 }
 
+// UpdateExpenseRequest changes the fields that are set; tags replace the
+// expense's tags.
 type UpdateExpenseRequest struct {
-	Amount   *float64 `json:"amount"`
-	Category *string  `json:"category"`
+	Amount       *float64  `json:"amount"`
+	Category     *string   `json:"category"`
+	Description  *string   `json:"description"`
+	Tags         *[]string `json:"tags"`
+	Reimbursable *bool     `json:"reimbursable"`
 }
 
 type ExpenseHandler struct {
@@ -33,71 +38,76 @@ func NewExpenseHandler(expenseService *services.ExpenseService) *ExpenseHandler
 }
 
 func (h *ExpenseHandler) AddExpenseHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
 	userID, exists := c.Get("user_id")
 
 	if !exists {
-		slog.Warn("Add expense is failed: user not logged in")
-		utils.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		logger.Warn("Add expense is failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
 		return
 	}
 	id, ok := userID.(int)
 	if !ok {
-		slog.Warn("invalid user type", "actual_type", fmt.Sprintf("%T", userID))
-		utils.RespondError(c, http.StatusInternalServerError, "internal server error")
+		logger.Warn("invalid user type", "actual_type", fmt.Sprintf("%T", userID))
+		c.Error(apperr.Internal(fmt.Errorf("unexpected user_id type %T", userID)))
 		return
 	}
 
 	var input ExpenseRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		slog.Warn("Add expense failed: invalid input", "error", err)
-		utils.RespondError(c, http.StatusBadRequest, "invalid input")
+		logger.Warn("Add expense failed: invalid input", "error", err)
+		c.Error(bindError(err))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// call service
 
-	expense, err := h.expenseService.AddExpenseService(ctx, id, input.Amount, input.Category)
+	expense, err := h.expenseService.AddExpenseService(ctx, id, input.Amount, input.Category, input.Description)
 	if err != nil {
-		slog.Warn("Add expense failed", "user_id", id, "error", err)
-		utils.RespondError(c, http.StatusBadRequest, "invalid input")
+		logger.Warn("Add expense failed", "user_id", id, "error", err)
+		c.Error(err)
 		return
 	}
-	slog.Info("expense created successfully", "user_id", id, "expense_id", expense.ID)
+	logger.Info("expense created successfully", "user_id", id, "expense_id", expense.ID)
 	c.JSON(http.StatusCreated, gin.H{
-		"id":         expense.ID,
-		"amount":     expense.Amount,
-		"category":   expense.Category,
-		"created_at": expense.CreatedAt,
+		"id":           expense.ID,
+		"amount":       expense.Amount,
+		"category":     expense.Category,
+		"description":  expense.Description,
+		"tags":         expense.Tags,
+		"reimbursable": expense.Reimbursable,
+		"created_at":   expense.CreatedAt,
 	})
 }
 
 func (h *ExpenseHandler) GetAllExpenseHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
 	userID, exits := c.Get("user_id")
 	if !exits {
-		slog.Warn("get expenses failed: user not logged in")
-		utils.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		logger.Warn("get expenses failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
 		return
 	}
 
 	id, ok := userID.(int)
 	if !ok || id <= 0 {
-		slog.Warn("invalid user_id", "user_id", userID)
-		utils.RespondError(c, http.StatusBadRequest, "invalid input")
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// call service
 
 	expenses, err := h.expenseService.GetAllExpenseService(ctx, id)
 	if err != nil {
-		slog.Error("failed to retrieve expenses", "user_id", id, "error", err)
-		utils.RespondError(c, http.StatusInternalServerError, "internal server error")
+		logger.Error("failed to retrieve expenses", "user_id", id, "error", err)
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -106,41 +116,44 @@ func (h *ExpenseHandler) GetAllExpenseHandler(c *gin.Context) {
 }
 
 func (h *ExpenseHandler) GetExpenseByIDHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
 	userID, exists := c.Get("user_id")
 	if !exists {
-		slog.Warn("failed to fetch expense: user not logged in")
-		utils.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		logger.Warn("failed to fetch expense: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
 		return
 	}
 
 	id, ok := userID.(int)
 	if !ok || id <= 0 {
-		slog.Warn("invalid user_id", "user_id", userID)
-		utils.RespondError(c, http.StatusBadRequest, "invalid input")
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
 		return
 	}
 	// get expenseID from URL
 	idStr := c.Param("id")
 	expenseID, err := strconv.Atoi(idStr)
 	if err != nil {
-		slog.Warn("invalid expense id", "user_id", id)
-		utils.RespondError(c, http.StatusBadRequest, "invalid expense id")
+		logger.Warn("invalid expense id", "user_id", id)
+		c.Error(apperr.Validation("invalid expense id", apperr.Field("id", "must be an integer")))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// call service
 	expense, err := h.expenseService.GetExpenseByIDService(ctx, expenseID, id)
 	if err != nil {
-		if errors.Is(err, services.ErrExpenseNotFound) {
-			slog.Info("expense not found", "expenseID", expenseID, "user_id", id)
-			utils.RespondError(c, http.StatusNotFound, "expense not found")
-			return
-		}
-		slog.Error("failed to fetch expense", "expenseID", expenseID, "user_id", id)
-		utils.RespondError(c, http.StatusInternalServerError, "internal server error")
+		logger.Warn("failed to fetch expense", "expenseID", expenseID, "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+
+	etag := expenseETag(expense.Version)
+	c.Header("ETag", etag)
+	if noneMatch(c, etag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -149,104 +162,292 @@ func (h *ExpenseHandler) GetExpenseByIDHandler(c *gin.Context) {
 }
 
 func (h *ExpenseHandler) UpdateExpenseHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
 	userID, exists := c.Get("user_id")
 	if !exists {
-		slog.Warn("failed to fetch expense: user not logged in")
-		utils.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		logger.Warn("failed to fetch expense: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
 		return
 	}
 
 	id, ok := userID.(int)
 	if !ok || id <= 0 {
-		slog.Warn("invalid user_id", "user_id", userID)
-		utils.RespondError(c, http.StatusBadRequest, "invalid input")
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
 		return
 	}
 	// get expenseID from URL
 	idStr := c.Param("id")
 	expenseID, err := strconv.Atoi(idStr)
 	if err != nil {
-		slog.Warn("invalid expense id", "user_id", id)
-		utils.RespondError(c, http.StatusBadRequest, "invalid expense id")
+		logger.Warn("invalid expense id", "user_id", id)
+		c.Error(apperr.Validation("invalid expense id", apperr.Field("id", "must be an integer")))
+		return
+	}
+
+	expectedVersion, ok := h.requireIfMatch(c, id, expenseID)
+	if !ok {
 		return
 	}
 
 	var input UpdateExpenseRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		slog.Warn("invalid amount or category", "user_id", id)
-		utils.RespondError(c, http.StatusBadRequest, "invalid input")
+		logger.Warn("invalid amount or category", "user_id", id)
+		c.Error(bindError(err))
 		return
 	}
 
 	// Map handler struct to service struct
 	serviceInput := services.UpdateExpenseInput{
-		Amount:   input.Amount,
-		Category: input.Category,
+		Amount:           input.Amount,
+		Category:         input.Category,
+		Description:      input.Description,
+		Tags:             input.Tags,
+		Reimbursable:     input.Reimbursable,
+		ExpectedVersions: expectedVersion,
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	h.updateExpense(c, id, expenseID, serviceInput)
+}
 
-	// call service
+// PatchExpenseHandler applies an RFC 7396 JSON Merge Patch to an expense.
+// Amount, category, description, tags and reimbursable are writable and none
+// may be removed with null; an empty tags list clears the tags.
+func (h *ExpenseHandler) PatchExpenseHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
 
-	updatedExpense, err := h.expenseService.UpdateExpenseService(ctx, expenseID, id, serviceInput)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("patch expense failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	idStr := c.Param("id")
+	expenseID, err := strconv.Atoi(idStr)
 	if err != nil {
-		if errors.Is(err, services.ErrExpenseNotFound) {
-			slog.Info("expense not found", "user_id", id, "expenseID", expenseID)
-			utils.RespondError(c, http.StatusNotFound, "expense not found")
+		logger.Warn("invalid expense id", "user_id", id)
+		c.Error(apperr.Validation("invalid expense id", apperr.Field("id", "must be an integer")))
+		return
+	}
+
+	if ct := c.ContentType(); ct != "application/merge-patch+json" && ct != "application/json" {
+		logger.Warn("patch expense failed: unsupported content type", "user_id", id, "content_type", ct)
+		c.Error(apperr.New(apperr.KindUnsupportedMediaType, "content type must be application/merge-patch+json"))
+		return
+	}
+
+	expectedVersion, ok := h.requireIfMatch(c, id, expenseID)
+	if !ok {
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(c.Request.Body).Decode(&patch); err != nil || patch == nil {
+		logger.Warn("patch expense failed: body is not a JSON object", "user_id", id)
+		c.Error(apperr.Validation("request body must be a JSON object"))
+		return
+	}
+
+	var serviceInput services.UpdateExpenseInput
+	for field, raw := range patch {
+		var problem string
+		switch {
+		case string(raw) == "null":
+			problem = "can not be removed"
+		case field == "amount":
+			if json.Unmarshal(raw, &serviceInput.Amount) != nil {
+				problem = "must be a number"
+			}
+		case field == "category":
+			if json.Unmarshal(raw, &serviceInput.Category) != nil {
+				problem = "must be a string"
+			}
+		case field == "description":
+			if json.Unmarshal(raw, &serviceInput.Description) != nil {
+				problem = "must be a string"
+			}
+		case field == "tags":
+			if json.Unmarshal(raw, &serviceInput.Tags) != nil {
+				problem = "must be an array of strings"
+			}
+		case field == "reimbursable":
+			if json.Unmarshal(raw, &serviceInput.Reimbursable) != nil {
+				problem = "must be a boolean"
+			}
+		default:
+			problem = "is not a writable field"
+		}
+		if problem != "" {
+			logger.Warn("patch expense failed: invalid field", "user_id", id, "field", field, "problem", problem)
+			c.Error(apperr.Validation("invalid merge patch", apperr.Field(field, problem)))
 			return
 		}
-		slog.Error("failed to update expense", "user_id", userID, "expenseID", expenseID)
-		utils.RespondError(c, http.StatusInternalServerError, err.Error())
+	}
+	serviceInput.ExpectedVersions = expectedVersion
+
+	h.updateExpense(c, id, expenseID, serviceInput)
+}
+
+// updateExpense is shared by PUT and PATCH once the input has been decoded.
+func (h *ExpenseHandler) updateExpense(c *gin.Context, id, expenseID int, serviceInput services.UpdateExpenseInput) {
+	logger := logging.FromContext(c.Request.Context())
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	updatedExpense, err := h.expenseService.UpdateExpenseService(ctx, expenseID, id, serviceInput)
+	if err != nil {
+		logger.Warn("failed to update expense", "user_id", id, "expenseID", expenseID, "error", err)
+		c.Error(err)
 		return
 	}
-	slog.Info("expense updated", "user_id", userID, "expenseID", expenseID)
+	logger.Info("expense updated", "user_id", id, "expenseID", expenseID)
+	c.Header("ETag", expenseETag(updatedExpense.Version))
 	c.JSON(http.StatusOK, gin.H{
 		"expense": updatedExpense,
 	})
 }
 
+// requireIfMatch parses If-Match and reports the error when it is missing
+// (428), malformed (400) or can never match (412).
+func (h *ExpenseHandler) requireIfMatch(c *gin.Context, id, expenseID int) (services.Versions, bool) {
+	logger := logging.FromContext(c.Request.Context())
+
+	versions, err := ifMatchVersions(c)
+	if err == nil {
+		return versions, true
+	}
+	logger.Warn("precondition check failed", "user_id", id, "expenseID", expenseID, "error", err)
+	c.Error(err)
+	return nil, false
+}
+
 func (h *ExpenseHandler) DeleteExpenseHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
 	userID, exists := c.Get("user_id")
 	if !exists {
-		slog.Warn("failed to fetch expense: user not logged in")
-		utils.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		logger.Warn("failed to fetch expense: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
 		return
 	}
 
 	id, ok := userID.(int)
 	if !ok || id <= 0 {
-		slog.Warn("invalid user_id", "user_id", userID)
-		utils.RespondError(c, http.StatusBadRequest, "invalid input")
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
 		return
 	}
 
 	idStr := c.Param("id")
 	expenseID, err := strconv.Atoi(idStr)
 	if err != nil {
-		slog.Warn("invalid expense id", "user_id", id)
-		utils.RespondError(c, http.StatusBadRequest, "invalid expense id")
+		logger.Warn("invalid expense id", "user_id", id)
+		c.Error(apperr.Validation("invalid expense id", apperr.Field("id", "must be an integer")))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	expectedVersion, ok := h.requireIfMatch(c, id, expenseID)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
 
 	// call service
 
-	err = h.expenseService.DeleteExpenseService(ctx, expenseID, id)
+	err = h.expenseService.DeleteExpenseService(ctx, expenseID, id, expectedVersion)
 	if err != nil {
-		if errors.Is(err, services.ErrExpenseNotFound) {
-			slog.Info("expense not found", "user_id", id, "expenseID", expenseID)
-			utils.RespondError(c, http.StatusNotFound, "expense not found")
-			return
-		}
-		slog.Error("failed to delete expense", "user_id", id, "expenseID", expenseID)
-		utils.RespondError(c, http.StatusInternalServerError, "internal server error")
+		logger.Warn("failed to delete expense", "user_id", id, "expenseID", expenseID, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("expense deleted", "user_id", id, "expenseID", expenseID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "expense moved to trash",
+	})
+}
+
+func (h *ExpenseHandler) GetTrashHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("get trash failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	expenses, err := h.expenseService.GetTrashService(ctx, id)
+	if err != nil {
+		logger.Error("failed to retrieve deleted expenses", "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"expenses": expenses,
+	})
+}
+
+func (h *ExpenseHandler) RestoreExpenseHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("restore expense failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
 		return
 	}
-	slog.Info("expense deleted", "user_id", id, "expenseID", expenseID)
+
+	idStr := c.Param("id")
+	expenseID, err := strconv.Atoi(idStr)
+	if err != nil {
+		logger.Warn("invalid expense id", "user_id", id)
+		c.Error(apperr.Validation("invalid expense id", apperr.Field("id", "must be an integer")))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	expense, err := h.expenseService.RestoreExpenseService(ctx, expenseID, id)
+	if err != nil {
+		logger.Warn("failed to restore expense", "user_id", id, "expenseID", expenseID, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("expense restored", "user_id", id, "expenseID", expenseID)
+	c.Header("ETag", expenseETag(expense.Version))
 	c.JSON(http.StatusOK, gin.H{
-		"message": "expense deleted successfully",
+		"expense": expense,
 	})
 }
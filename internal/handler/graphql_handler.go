@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/graph"
+	"expense-tracker/internal/logging"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GraphQLHandler struct {
+	schema *graph.Schema
+}
+
+func NewGraphQLHandler(schema *graph.Schema) *GraphQLHandler {
+	return &GraphQLHandler{schema: schema}
+}
+
+// QueryHandler executes a GraphQL query. Like any GraphQL server it answers
+// 200 once the request is well-formed; query failures are in "errors".
+func (h *GraphQLHandler) QueryHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("graphql query failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	var req graph.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn("invalid graphql request", "user_id", id, "error", err)
+		c.Error(bindError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	resp := h.schema.Exec(ctx, id, req)
+	if len(resp.Errors) > 0 {
+		logger.Warn("graphql query returned errors", "user_id", id, "operation", req.OperationName, "errors", len(resp.Errors))
+	}
+	c.JSON(http.StatusOK, resp)
+}
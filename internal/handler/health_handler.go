@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"expense-tracker/internal/health"
+	"expense-tracker/internal/logging"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// LivezHandler only reports that the process is serving requests; it never
+// checks dependencies, so a database outage doesn't get the pod restarted.
+func (h *HealthHandler) LivezHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": health.StatusUp,
+	})
+}
+
+// ReadyzHandler reports whether this instance should receive traffic.
+func (h *HealthHandler) ReadyzHandler(c *gin.Context) {
+	report := h.checker.Ready(c.Request.Context())
+	if report.Status != health.StatusUp {
+		logging.FromContext(c.Request.Context()).Warn("readiness check failed", "checks", report.Checks)
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
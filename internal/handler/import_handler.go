@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/services"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MultipartOverhead is room for the multipart framing around an uploaded
+// statement; the router's body limit for uploads is the file limit plus this.
+const MultipartOverhead = 64 << 10
+
+type CandidateRequest struct {
+	Amount   *float64 `json:"amount"`
+	Category *string  `json:"category"`
+}
+
+type ReviewRequest struct {
+	Decisions []ReviewDecisionRequest `json:"decisions" binding:"required,min=1,dive"`
+}
+
+type ReviewDecisionRequest struct {
+	CandidateID int64    `json:"candidate_id" binding:"required"`
+	Action      string   `json:"action" binding:"required,oneof=accept discard"`
+	Amount      *float64 `json:"amount"`
+	Category    *string  `json:"category"`
+}
+
+type ImportHandler struct {
+	importService *services.ImportService
+	maxFileBytes  int64
+}
+
+func NewImportHandler(importService *services.ImportService, maxFileBytes int) *ImportHandler {
+	return &ImportHandler{importService: importService, maxFileBytes: int64(maxFileBytes)}
+}
+
+// UploadStatementHandler reads an OFX, QFX or CAMT.053 file from the
+// multipart field "file" and stores its new debits as pending candidates.
+func (h *ImportHandler) UploadStatementHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("upload statement failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	// the router's body limit has already capped what can be read here
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Error(apperr.New(apperr.KindPayloadTooLarge, "statement file too large"))
+			return
+		}
+		logger.Warn("upload statement failed: no file", "error", err)
+		c.Error(apperr.Validation("a statement file is required", apperr.Field("file", "is required")))
+		return
+	}
+	if header.Size > h.maxFileBytes {
+		c.Error(apperr.New(apperr.KindPayloadTooLarge, "statement file too large"))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	result, err := h.importService.UploadStatementService(ctx, id, header.Filename, data)
+	if err != nil {
+		logger.Warn("upload statement failed", "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("statement imported", "user_id", id, "import_id", result.Import.ID,
+		"candidates", result.Import.Pending, "duplicates", result.Duplicates)
+	c.JSON(http.StatusCreated, gin.H{
+		"import":     result.Import,
+		"duplicates": result.Duplicates,
+		"credits":    result.Credits,
+	})
+}
+
+// ListImportsHandler pages through the user's imports, newest first, with
+// ?limit= and ?before=<next_before of the previous page>.
+func (h *ImportHandler) ListImportsHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("list imports failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.Error(apperr.Validation("invalid limit", apperr.Field("limit", "must be an integer")))
+		return
+	}
+	before, err := strconv.Atoi(c.DefaultQuery("before", "0"))
+	if err != nil {
+		c.Error(apperr.Validation("invalid before", apperr.Field("before", "must be an integer")))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	imports, err := h.importService.ListImportsService(ctx, id, before, limit)
+	if err != nil {
+		logger.Error("failed to fetch imports", "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+
+	response := gin.H{"imports": imports}
+	if len(imports) > 0 {
+		response["next_before"] = imports[len(imports)-1].ID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetImportHandler returns an import with its candidates; ?status= narrows
+// them to pending, accepted or discarded.
+func (h *ImportHandler) GetImportHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("get import failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	importID, ok := importIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	imp, candidates, err := h.importService.GetImportService(ctx, importID, id, c.Query("status"))
+	if err != nil {
+		logger.Warn("failed to fetch import", "user_id", id, "import_id", importID, "error", err)
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"import":     imp,
+		"candidates": candidates,
+	})
+}
+
+// UpdateCandidateHandler edits a pending candidate's amount or category
+// ahead of the review.
+func (h *ImportHandler) UpdateCandidateHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("update candidate failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	importID, ok := importIDParam(c)
+	if !ok {
+		return
+	}
+	candidateID, err := strconv.ParseInt(c.Param("candidateId"), 10, 64)
+	if err != nil {
+		c.Error(apperr.Validation("invalid candidate id", apperr.Field("candidateId", "must be an integer")))
+		return
+	}
+
+	var input CandidateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Warn("update candidate failed: invalid input", "error", err)
+		c.Error(bindError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	candidate, err := h.importService.UpdateCandidateService(ctx, importID, id, candidateID, services.CandidateEdit{
+		Amount:   input.Amount,
+		Category: input.Category,
+	})
+	if err != nil {
+		logger.Warn("failed to update candidate", "user_id", id, "import_id", importID, "candidate_id", candidateID, "error", err)
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, candidate)
+}
+
+// ReviewImportHandler accepts or discards candidates. The decisions are
+// applied together: if one fails, none is.
+func (h *ImportHandler) ReviewImportHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("review import failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	importID, ok := importIDParam(c)
+	if !ok {
+		return
+	}
+
+	var input ReviewRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Warn("review import failed: invalid input", "error", err)
+		c.Error(bindError(err))
+		return
+	}
+
+	decisions := make([]services.ReviewDecision, len(input.Decisions))
+	for i, decision := range input.Decisions {
+		decisions[i] = services.ReviewDecision{
+			CandidateID: decision.CandidateID,
+			Action:      decision.Action,
+			CandidateEdit: services.CandidateEdit{
+				Amount:   decision.Amount,
+				Category: decision.Category,
+			},
+		}
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	result, err := h.importService.ReviewImportService(ctx, importID, id, decisions)
+	if err != nil {
+		logger.Warn("review import failed", "user_id", id, "import_id", importID, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("import reviewed", "user_id", id, "import_id", importID,
+		"decisions", len(result.Candidates), "accepted", len(result.Expenses))
+	c.JSON(http.StatusOK, gin.H{
+		"candidates": result.Candidates,
+		"expenses":   result.Expenses,
+	})
+}
+
+func importIDParam(c *gin.Context) (int, bool) {
+	importID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || importID <= 0 {
+		c.Error(apperr.Validation("invalid import id", apperr.Field("id", "must be a positive integer")))
+		return 0, false
+	}
+	return importID, true
+}
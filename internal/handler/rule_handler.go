@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRulePriority places rules created without a priority after those
+// given a small one.
+const defaultRulePriority = 100
+
+type RuleRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Priority *int   `json:"priority"`
+	Enabled  *bool  `json:"enabled"`
+
+	DescriptionContains *string  `json:"description_contains"`
+	DescriptionRegex    *string  `json:"description_regex"`
+	MinAmount           *float64 `json:"min_amount"`
+	MaxAmount           *float64 `json:"max_amount"`
+	Weekdays            []string `json:"weekdays"`
+
+	SetCategory      *string  `json:"set_category"`
+	AddTags          []string `json:"add_tags"`
+	MarkReimbursable bool     `json:"mark_reimbursable"`
+}
+
+func (r RuleRequest) rule() *model.Rule {
+	rule := &model.Rule{
+		Name:                r.Name,
+		Priority:            defaultRulePriority,
+		Enabled:             true,
+		DescriptionContains: r.DescriptionContains,
+		DescriptionRegex:    r.DescriptionRegex,
+		MinAmount:           r.MinAmount,
+		MaxAmount:           r.MaxAmount,
+		Weekdays:            r.Weekdays,
+		SetCategory:         r.SetCategory,
+		AddTags:             r.AddTags,
+		MarkReimbursable:    r.MarkReimbursable,
+	}
+	if r.Priority != nil {
+		rule.Priority = *r.Priority
+	}
+	if r.Enabled != nil {
+		rule.Enabled = *r.Enabled
+	}
+	return rule
+}
+
+// ApplyRulesRequest previews unless dry_run is explicitly false: applying
+// overwrites categories and cannot be undone.
+type ApplyRulesRequest struct {
+	DryRun  *bool `json:"dry_run"`
+	AfterID int   `json:"after_id" binding:"min=0"`
+	Limit   int   `json:"limit" binding:"min=0"`
+}
+
+type RuleHandler struct {
+	ruleService *services.RuleService
+}
+
+func NewRuleHandler(ruleService *services.RuleService) *RuleHandler {
+	return &RuleHandler{ruleService: ruleService}
+}
+
+// ListRulesHandler returns the user's rules in the order they run.
+func (h *RuleHandler) ListRulesHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("list rules failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	list, err := h.ruleService.ListRulesService(ctx, id)
+	if err != nil {
+		logger.Error("failed to fetch rules", "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"rules": list,
+	})
+}
+
+func (h *RuleHandler) CreateRuleHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("create rule failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	var input RuleRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Warn("create rule failed: invalid input", "error", err)
+		c.Error(bindError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	rule, err := h.ruleService.CreateRuleService(ctx, id, input.rule())
+	if err != nil {
+		logger.Warn("create rule failed", "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("rule created", "user_id", id, "rule_id", rule.ID)
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *RuleHandler) GetRuleHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("get rule failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	ruleID, ok := ruleIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	rule, err := h.ruleService.GetRuleService(ctx, ruleID, id)
+	if err != nil {
+		logger.Warn("failed to fetch rule", "user_id", id, "rule_id", ruleID, "error", err)
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRuleHandler replaces a rule; settings left out take their defaults.
+func (h *RuleHandler) UpdateRuleHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("update rule failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	ruleID, ok := ruleIDParam(c)
+	if !ok {
+		return
+	}
+
+	var input RuleRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Warn("update rule failed: invalid input", "error", err)
+		c.Error(bindError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	rule := input.rule()
+	rule.ID = ruleID
+	updated, err := h.ruleService.UpdateRuleService(ctx, id, rule)
+	if err != nil {
+		logger.Warn("failed to update rule", "user_id", id, "rule_id", ruleID, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("rule updated", "user_id", id, "rule_id", ruleID)
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *RuleHandler) DeleteRuleHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("delete rule failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	ruleID, ok := ruleIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	if err := h.ruleService.DeleteRuleService(ctx, ruleID, id); err != nil {
+		logger.Warn("failed to delete rule", "user_id", id, "rule_id", ruleID, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("rule deleted", "user_id", id, "rule_id", ruleID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "rule deleted",
+	})
+}
+
+// ApplyRulesHandler runs the rules over existing expenses. By default it only
+// reports what would change; dry_run false applies the changes.
+func (h *RuleHandler) ApplyRulesHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("apply rules failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	var input ApplyRulesRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Warn("apply rules failed: invalid input", "error", err)
+		c.Error(bindError(err))
+		return
+	}
+
+	dryRun := input.DryRun == nil || *input.DryRun
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	result, err := h.ruleService.ApplyRulesService(ctx, id, dryRun, input.AfterID, input.Limit)
+	if err != nil {
+		logger.Warn("apply rules failed", "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+	if !dryRun {
+		logger.Info("rules applied", "user_id", id, "changed", len(result.Changes))
+	}
+	response := gin.H{
+		"dry_run":  dryRun,
+		"changes":  result.Changes,
+		"has_more": result.HasMore,
+	}
+	if result.HasMore {
+		response["next_after_id"] = result.NextAfterID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func ruleIDParam(c *gin.Context) (int, bool) {
+	ruleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || ruleID <= 0 {
+		c.Error(apperr.Validation("invalid rule id", apperr.Field("id", "must be a positive integer")))
+		return 0, false
+	}
+	return ruleID, true
+}
@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/services"
+	"expense-tracker/internal/stream"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const streamWriteTimeout = 10 * time.Second
+
+type StreamHandler struct {
+	eventService *services.EventService
+	broker       *stream.Broker
+	heartbeat    time.Duration
+	upgrader     websocket.Upgrader
+}
+
+// NewStreamHandler serves expense streams. allowedOrigins are the CORS
+// origins; browsers on other origins may not open the WebSocket.
+func NewStreamHandler(eventService *services.EventService, broker *stream.Broker, heartbeat time.Duration, allowedOrigins []string) *StreamHandler {
+	return &StreamHandler{
+		eventService: eventService,
+		broker:       broker,
+		heartbeat:    heartbeat,
+		upgrader: websocket.Upgrader{
+			HandshakeTimeout: streamWriteTimeout,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				if origin == "" || slices.Contains(allowedOrigins, "*") || slices.Contains(allowedOrigins, origin) {
+					return true
+				}
+				u, err := url.Parse(origin)
+				return err == nil && u.Host == r.Host
+			},
+		},
+	}
+}
+
+// ExpenseStreamHandler pushes the user's expense changes as Server-Sent
+// Events. Each event's id is its seq, its position in the user's event log;
+// a client reconnecting with Last-Event-ID (or ?last_event_id=) gets
+// everything it missed, a new client only what happens from now on. A
+// client whose missed events have been purged gets a stream.reset first.
+func (h *StreamHandler) ExpenseStreamHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	id, sub, afterSeq, reset, ok := h.open(c, logger)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	disableDeadlines(c, logger)
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // keep nginx from buffering the stream
+	c.Status(http.StatusOK)
+
+	sink := &sseSink{w: c.Writer, rc: http.NewResponseController(c.Writer)}
+	if err := sink.write("retry: 3000\n\n"); err != nil {
+		return
+	}
+	if reset {
+		if err := sink.Reset(afterSeq); err != nil {
+			return
+		}
+	}
+
+	logger.Info("expense stream opened", "user_id", id, "after", afterSeq, "reset", reset)
+	err := stream.Follow(c.Request.Context(), h.broker, sub, afterSeq, h.heartbeat, h.fetch(id), sink)
+	logger.Info("expense stream closed", "user_id", id, "error", err)
+}
+
+// ExpenseWebSocketHandler is ExpenseStreamHandler over a WebSocket: every
+// event is a JSON text message and heartbeats are pings.
+func (h *StreamHandler) ExpenseWebSocketHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	id, sub, afterSeq, reset, ok := h.open(c, logger)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	disableDeadlines(c, logger)
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		logger.Warn("websocket upgrade failed", "user_id", id, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// the client sends nothing; reading only handles pongs and its close
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	sink := &wsSink{conn: conn}
+	if reset {
+		if err := sink.Reset(afterSeq); err != nil {
+			return
+		}
+	}
+
+	logger.Info("expense websocket opened", "user_id", id, "after", afterSeq, "reset", reset)
+	err = stream.Follow(ctx, h.broker, sub, afterSeq, h.heartbeat, h.fetch(id), sink)
+	logger.Info("expense websocket closed", "user_id", id, "error", err)
+
+	code, reason := websocket.CloseGoingAway, "stream closed"
+	if err != nil && ctx.Err() == nil {
+		code, reason = websocket.CloseInternalServerErr, "stream failed"
+	}
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+}
+
+// open authenticates, works out where the stream starts and subscribes. On
+// failure the error is already recorded on c.
+func (h *StreamHandler) open(c *gin.Context, logger *slog.Logger) (int, *stream.Subscription, int64, bool, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("open stream failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return 0, nil, 0, false, false
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return 0, nil, 0, false, false
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var resumeAfter *int64
+	if lastEventID != "" {
+		seq, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || seq < 0 {
+			c.Error(apperr.Validation("invalid last event id", apperr.Field("Last-Event-ID", "must be a non-negative integer")))
+			return 0, nil, 0, false, false
+		}
+		resumeAfter = &seq
+	}
+
+	// subscribe before looking up the start so nothing slips in between
+	sub, err := h.broker.Subscribe(id)
+	if err != nil {
+		logger.Warn("open stream failed", "user_id", id, "error", err)
+		c.Error(err)
+		return 0, nil, 0, false, false
+	}
+	afterSeq, reset, err := h.eventService.StreamStartService(c.Request.Context(), id, resumeAfter)
+	if err != nil {
+		sub.Close()
+		logger.Error("open stream failed", "user_id", id, "error", err)
+		c.Error(err)
+		return 0, nil, 0, false, false
+	}
+	return id, sub, afterSeq, reset, true
+}
+
+func (h *StreamHandler) fetch(userID int) stream.Fetch {
+	return func(ctx context.Context, afterSeq int64, limit int) ([]*model.OutboxEvent, error) {
+		return h.eventService.ListExpenseEventsService(ctx, userID, afterSeq, limit)
+	}
+}
+
+// disableDeadlines lifts the server's read and write timeouts, which would
+// otherwise cut a stream off after a few seconds.
+func disableDeadlines(c *gin.Context, logger *slog.Logger) {
+	rc := http.NewResponseController(c.Writer)
+	err := errors.Join(rc.SetReadDeadline(time.Time{}), rc.SetWriteDeadline(time.Time{}))
+	if err != nil {
+		logger.Warn("could not lift deadlines for stream", "error", err)
+	}
+}
+
+type sseSink struct {
+	w  gin.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (s *sseSink) Event(event *model.OutboxEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.write(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data))
+}
+
+func (s *sseSink) Reset(seq int64) error {
+	data, err := json.Marshal(resetMessage(seq))
+	if err != nil {
+		return err
+	}
+	return s.write(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", seq, stream.ResetEvent, data))
+}
+
+func (s *sseSink) Heartbeat() error {
+	return s.write(": heartbeat\n\n")
+}
+
+func (s *sseSink) write(frame string) error {
+	// a client that stops reading must not hold the stream open forever
+	if err := s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := s.w.WriteString(frame); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+type wsSink struct {
+	conn *websocket.Conn
+}
+
+func (s *wsSink) Event(event *model.OutboxEvent) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		return err
+	}
+	return s.conn.WriteJSON(event)
+}
+
+func (s *wsSink) Reset(seq int64) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		return err
+	}
+	return s.conn.WriteJSON(resetMessage(seq))
+}
+
+func (s *wsSink) Heartbeat() error {
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+}
+
+func resetMessage(seq int64) gin.H {
+	return gin.H{
+		"type":    stream.ResetEvent,
+		"seq":     seq,
+		"message": "events since the last one received have expired; reload expenses before following the stream",
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SyncRequest struct {
+	SyncToken string              `json:"sync_token"`
+	Limit     int                 `json:"limit" binding:"min=0"`
+	Changes   []SyncChangeRequest `json:"changes" binding:"dive"`
+}
+
+type SyncChangeRequest struct {
+	Op           string     `json:"op" binding:"required,oneof=create update delete"`
+	UUID         string     `json:"uuid" binding:"required"`
+	BaseVersion  *int       `json:"base_version"`
+	Amount       *float64   `json:"amount"`
+	Category     *string    `json:"category"`
+	Description  *string    `json:"description"`
+	Tags         *[]string  `json:"tags"`
+	Reimbursable *bool      `json:"reimbursable"`
+	CreatedAt    *time.Time `json:"created_at"`
+}
+
+type SyncHandler struct {
+	syncService *services.SyncService
+}
+
+func NewSyncHandler(syncService *services.SyncService) *SyncHandler {
+	return &SyncHandler{syncService: syncService}
+}
+
+// SyncHandler applies an offline client's changes and answers with the
+// server's changes since its sync_token. Clients call it again with the
+// returned token while has_more is set.
+func (h *SyncHandler) SyncHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("sync failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	var input SyncRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Warn("sync failed: invalid input", "error", err)
+		c.Error(bindError(err))
+		return
+	}
+
+	changes := make([]services.SyncChange, len(input.Changes))
+	for i, change := range input.Changes {
+		changes[i] = services.SyncChange{
+			Op:           change.Op,
+			UUID:         change.UUID,
+			BaseVersion:  change.BaseVersion,
+			Amount:       change.Amount,
+			Category:     change.Category,
+			Description:  change.Description,
+			Tags:         change.Tags,
+			Reimbursable: change.Reimbursable,
+			CreatedAt:    change.CreatedAt,
+		}
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	result, err := h.syncService.SyncExpensesService(ctx, id, services.SyncInput{
+		Token:   input.SyncToken,
+		Changes: changes,
+		Limit:   input.Limit,
+	})
+	if err != nil {
+		logger.Warn("sync failed", "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("sync completed", "user_id", id, "applied", len(result.Applied), "conflicts", len(result.Conflicts), "changes", len(result.Changes))
+	c.JSON(http.StatusOK, gin.H{
+		"applied":    result.Applied,
+		"conflicts":  result.Conflicts,
+		"changes":    result.Changes,
+		"sync_token": result.Token,
+		"has_more":   result.HasMore,
+	})
+}
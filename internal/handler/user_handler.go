@@ -1,13 +1,12 @@
 package handler
 
 import (
-	"context"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
 	"expense-tracker/internal/services"
 	"expense-tracker/internal/utils"
 	"fmt"
-	"log/slog"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,33 +23,34 @@ type LogInRequest struct {
 
 type UserHandler struct {
 	userService *services.UserService
+	tokens      *utils.TokenManager
 }
 
-func NewUserHandler(userService *services.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService *services.UserService, tokens *utils.TokenManager) *UserHandler {
+	return &UserHandler{userService: userService, tokens: tokens}
 }
 
 func (h *UserHandler) CreateUserHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
 
 	var input RegisterRequest
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		slog.Warn("register failed: invalid input", "error", err)
-		utils.RespondError(c, http.StatusBadRequest, "invalid input")
+		logger.Warn("register failed: invalid input", "error", err)
+		c.Error(bindError(err))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	user, err := h.userService.RegisterUser(ctx, input.Email, input.Password)
 
 	if err != nil {
-		slog.Warn("register failed: email may already exist", "email", input.Email) // check pgxerror
-		utils.RespondError(c, http.StatusInternalServerError, "internal server error")
+		logger.Warn("register failed", "error", err)
+		c.Error(err)
 		return
 	}
-	slog.Info("user registered successfully", "user_id", user.ID, "email", user.Email)
+	logger.Info("user registered successfully", "user_id", user.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"id":         user.ID,
@@ -60,35 +60,35 @@ func (h *UserHandler) CreateUserHandler(c *gin.Context) {
 }
 
 func (h *UserHandler) LogInUserHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
 
 	var input LogInRequest
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		slog.Warn("log in failed: invalid input", "error", err)
-		utils.RespondError(c, http.StatusBadRequest, "invalid input")
+		logger.Warn("log in failed: invalid input", "error", err)
+		c.Error(bindError(err))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	//service call
 
 	user, err := h.userService.LogInUserService(ctx, input.Email, input.Password)
 	if err != nil {
-		slog.Warn("log in failed", "invalid credentials", input.Email)
-		utils.RespondError(c, http.StatusUnauthorized, "invalid email or password")
+		logger.Warn("log in failed", "error", err)
+		c.Error(err)
 		return
 	}
 
-	token, err := utils.GenerateToken(int64(user.ID))
+	token, err := h.tokens.GenerateToken(int64(user.ID))
 
 	if err != nil {
-		slog.Error("token generation error", "userID", user.ID)
-		utils.RespondError(c, http.StatusInternalServerError, "internal server error")
+		logger.Error("token generation error", "userID", user.ID, "error", err)
+		c.Error(apperr.Internal(err))
 		return
 	}
-	slog.Info(" log in successfull", "user_id", user.ID)
+	logger.Info(" log in successfull", "user_id", user.ID)
 	c.JSON(http.StatusOK, gin.H{
 		"token": token,
 		"user": gin.H{
@@ -99,30 +99,31 @@ func (h *UserHandler) LogInUserHandler(c *gin.Context) {
 }
 
 func (h *UserHandler) GetUserHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
 	// get id from context
 	userID, exists := c.Get("user_id")
 	if !exists {
-		slog.Warn("user is not logged in")
-		utils.RespondError(c, http.StatusUnauthorized, "unauthorized")
+		logger.Warn("user is not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
 		return
 	}
 
 	id, ok := userID.(int) // middleware me userID is int
 	if !ok {
-		slog.Warn("invalid user type", "actual_type", fmt.Sprintf("%T", userID))
-		utils.RespondError(c, http.StatusInternalServerError, "internal server error")
+		logger.Warn("invalid user type", "actual_type", fmt.Sprintf("%T", userID))
+		c.Error(apperr.Internal(fmt.Errorf("unexpected user_id type %T", userID)))
 		return
 	}
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	user, err := h.userService.GetUserService(ctx, id)
 	if err != nil {
-		slog.Warn("user not found", "user_id", id)
-		utils.RespondError(c, http.StatusNotFound, "user not found")
+		logger.Warn("failed to fetch user", "user_id", id, "error", err)
+		c.Error(err)
 		return
 	}
-	slog.Info("user profile pulled successfully", "user_id", userID)
+	logger.Info("user profile pulled successfully", "user_id", userID)
 	c.JSON(http.StatusOK, gin.H{
 		"user_id":    user.ID,
 		"email":      user.Email,
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required,min=1"`
+}
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// CreateWebhookHandler registers an endpoint. The response is the only place
+// the signing secret is ever shown.
+func (h *WebhookHandler) CreateWebhookHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("create webhook failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	var input WebhookRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Warn("create webhook failed: invalid input", "error", err)
+		c.Error(bindError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	endpoint, err := h.webhookService.CreateWebhookService(ctx, id, input.URL, input.Events)
+	if err != nil {
+		logger.Warn("create webhook failed", "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("webhook created", "user_id", id, "webhook_id", endpoint.ID)
+	c.JSON(http.StatusCreated, gin.H{
+		"id":         endpoint.ID,
+		"url":        endpoint.URL,
+		"events":     endpoint.Events,
+		"secret":     endpoint.Secret,
+		"created_at": endpoint.CreatedAt,
+	})
+}
+
+func (h *WebhookHandler) ListWebhooksHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("list webhooks failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	endpoints, err := h.webhookService.ListWebhooksService(ctx, id)
+	if err != nil {
+		logger.Error("failed to fetch webhooks", "user_id", id, "error", err)
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": endpoints,
+	})
+}
+
+func (h *WebhookHandler) GetWebhookHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("get webhook failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	webhookID, ok := webhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	endpoint, err := h.webhookService.GetWebhookService(ctx, webhookID, id)
+	if err != nil {
+		logger.Warn("failed to fetch webhook", "user_id", id, "webhook_id", webhookID, "error", err)
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+func (h *WebhookHandler) DeleteWebhookHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("delete webhook failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	webhookID, ok := webhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	if err := h.webhookService.DeleteWebhookService(ctx, webhookID, id); err != nil {
+		logger.Warn("failed to delete webhook", "user_id", id, "webhook_id", webhookID, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("webhook deleted", "user_id", id, "webhook_id", webhookID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook deleted",
+	})
+}
+
+// ListDeliveriesHandler pages through a webhook's deliveries, newest first.
+// ?status= narrows them to pending, delivered or dead; pages are requested
+// with ?limit= and ?before=<next_before of the previous page>.
+func (h *WebhookHandler) ListDeliveriesHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("list deliveries failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	webhookID, ok := webhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.Error(apperr.Validation("invalid limit", apperr.Field("limit", "must be an integer")))
+		return
+	}
+	before, err := strconv.ParseInt(c.DefaultQuery("before", "0"), 10, 64)
+	if err != nil {
+		c.Error(apperr.Validation("invalid before", apperr.Field("before", "must be an integer")))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	deliveries, err := h.webhookService.ListDeliveriesService(ctx, webhookID, id, c.Query("status"), before, limit)
+	if err != nil {
+		logger.Warn("failed to fetch deliveries", "user_id", id, "webhook_id", webhookID, "error", err)
+		c.Error(err)
+		return
+	}
+
+	response := gin.H{"deliveries": deliveries}
+	if len(deliveries) > 0 {
+		response["next_before"] = deliveries[len(deliveries)-1].ID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RetryDeliveryHandler queues a dead delivery again.
+func (h *WebhookHandler) RetryDeliveryHandler(c *gin.Context) {
+	logger := logging.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		logger.Warn("retry delivery failed: user not logged in")
+		c.Error(apperr.Unauthorized("authentication required"))
+		return
+	}
+
+	id, ok := userID.(int)
+	if !ok || id <= 0 {
+		logger.Warn("invalid user_id", "user_id", userID)
+		c.Error(apperr.Unauthorized("invalid token subject"))
+		return
+	}
+
+	webhookID, ok := webhookIDParam(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("deliveryId"), 10, 64)
+	if err != nil {
+		c.Error(apperr.Validation("invalid delivery id", apperr.Field("deliveryId", "must be an integer")))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// call service
+
+	delivery, err := h.webhookService.RetryDeliveryService(ctx, webhookID, id, deliveryID)
+	if err != nil {
+		logger.Warn("failed to retry delivery", "user_id", id, "webhook_id", webhookID, "delivery_id", deliveryID, "error", err)
+		c.Error(err)
+		return
+	}
+	logger.Info("delivery queued for retry", "user_id", id, "webhook_id", webhookID, "delivery_id", deliveryID)
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func webhookIDParam(c *gin.Context) (int, bool) {
+	webhookID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || webhookID <= 0 {
+		c.Error(apperr.Validation("invalid webhook id", apperr.Field("id", "must be a positive integer")))
+		return 0, false
+	}
+	return webhookID, true
+}
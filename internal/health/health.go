@@ -0,0 +1,130 @@
+// Package health implements the liveness and readiness checks behind /livez and /readyz.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckResult is the outcome of checking one dependency.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the readiness of the whole instance.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type Checker struct {
+	pool            *pgxpool.Pool
+	expectedVersion uint
+	timeout         time.Duration
+	shuttingDown    atomic.Bool
+	// lastReady is the outcome of the last readiness check before shutdown.
+	lastReady atomic.Bool
+}
+
+// NewChecker checks pool and expects the schema to be at expectedVersion.
+// Each dependency check is bounded by timeout.
+func NewChecker(pool *pgxpool.Pool, expectedVersion uint, timeout time.Duration) *Checker {
+	return &Checker{pool: pool, expectedVersion: expectedVersion, timeout: timeout}
+}
+
+// SetShuttingDown makes every following readiness check fail so load
+// balancers stop routing here before the server starts draining.
+func (h *Checker) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// ReportedReady reports whether the last readiness check before shutdown
+// passed, that is whether load balancers may still be routing here.
+func (h *Checker) ReportedReady() bool {
+	return h.lastReady.Load()
+}
+
+// Ready runs every dependency check concurrently.
+func (h *Checker) Ready(ctx context.Context) Report {
+	checks := map[string]func(context.Context) error{
+		"database":   h.checkDatabase,
+		"migrations": h.checkMigrations,
+		"shutdown":   h.checkShutdown,
+	}
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(ctx)
+			result := CheckResult{Status: StatusUp, Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = StatusDown
+			}
+		})
+	}
+	wg.Wait()
+	if !h.shuttingDown.Load() {
+		h.lastReady.Store(report.Status == StatusUp)
+	}
+	return report
+}
+
+func (h *Checker) checkShutdown(context.Context) error {
+	if h.shuttingDown.Load() {
+		return errors.New("shutting down")
+	}
+	return nil
+}
+
+func (h *Checker) checkDatabase(ctx context.Context) error {
+	return h.pool.Ping(ctx)
+}
+
+// checkMigrations compares the schema_migrations table kept by the migration
+// tool against the version this binary was built for.
+func (h *Checker) checkMigrations(ctx context.Context) error {
+	var version uint
+	var dirty bool
+	err := h.pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("no migrations applied")
+		}
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d failed and left the schema dirty", version)
+	}
+	if version != h.expectedVersion {
+		return fmt.Errorf("schema is at version %d, expected %d", version, h.expectedVersion)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package metrics defines the Prometheus collectors exposed on the metrics
+// port: HTTP traffic, the database pool and business events.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every collector of this process. A private registry keeps
+// third-party packages from adding metrics behind our back.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by route, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	DBReads = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_reads_total",
+		Help: "Read-only repository queries by the pool that served them.",
+	}, []string{"pool"})
+
+	ExpensesCreated = factory.NewCounter(prometheus.CounterOpts{
+		Name: "expenses_created_total",
+		Help: "Expenses created, including batch creates.",
+	})
+
+	UsersRegistered = factory.NewCounter(prometheus.CounterOpts{
+		Name: "users_registered_total",
+		Help: "Users registered.",
+	})
+
+	LoginsFailed = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "logins_failed_total",
+		Help: "Failed login attempts by reason.",
+	}, []string{"reason"})
+
+	WebhookDeliveries = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_deliveries_total",
+		Help: "Webhook delivery attempts by result: delivered, retry or dead.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
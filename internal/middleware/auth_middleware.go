@@ -1,53 +1,44 @@
 package middleware
 
 import (
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
 	"expense-tracker/internal/utils"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(tokens *utils.TokenManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		authHeader := c.GetHeader("Authorization")
 
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
+			c.Error(apperr.Unauthorized("missing token"))
+			c.Abort()
 			return
 		}
 
 		tokenParts := strings.Fields(authHeader)
 
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token format"})
+			c.Error(apperr.Unauthorized("invalid token format"))
+			c.Abort()
 			return
 		}
 
 		tokenString := tokenParts[1]
 
-		token, err := utils.ValidateToken(tokenString)
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		userID, err := tokens.Authenticate(tokenString)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			return
-		}
-
-		userID, ok := claims["user_id"].(float64)
-
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user_id missing"})
-			return
-		}
-
-		c.Set("user_id", int(userID))
+		c.Set("user_id", userID)
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), "user_id", userID))
 		c.Next()
 	}
 }
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"expense-tracker/internal/apperr"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit caps the request body at the route's entry in overrides, keyed by
+// "METHOD /path" pattern, or def otherwise. A declared Content-Length over the
+// limit is rejected with 413 straight away; otherwise reading past the limit
+// fails with *http.MaxBytesError. It must run before anything that reads the
+// body, OpenAPI validation included.
+func BodyLimit(def int64, overrides map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := def
+		if n, ok := overrides[c.Request.Method+" "+c.FullPath()]; ok {
+			limit = n
+		}
+		if c.Request.ContentLength > limit {
+			c.Error(apperr.New(apperr.KindPayloadTooLarge, "request body too large"))
+			c.Abort()
+			return
+		}
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		c.Next()
+	}
+}
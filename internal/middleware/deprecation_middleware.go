@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks the responses of a route that is going away: Deprecation
+// (RFC 9745) says since when, Sunset (RFC 8594) when it stops working, and a
+// successor-version Link names the replacement. successor is a route
+// pattern; its :params are filled in from the request.
+func Deprecated(since, sunset time.Time, successor string) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", since.Unix())
+	sunsetAt := sunset.UTC().Format(http.TimeFormat)
+
+	return func(c *gin.Context) {
+		link := successor
+		for _, param := range c.Params {
+			link = strings.Replace(link, ":"+param.Key, url.PathEscape(param.Value), 1)
+		}
+
+		header := c.Writer.Header()
+		header.Set("Deprecation", deprecation)
+		header.Set("Sunset", sunsetAt)
+		header.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", link))
+		c.Next()
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/reqctx"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Errors renders the last error attached with c.Error as an RFC 7807
+// application/problem+json response, unless a response was already written.
+// Untyped errors become a generic 500 so internal details never reach
+// clients. Handlers and middleware report failures with c.Error and return
+// (middleware also calls c.Abort).
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		renderError(c)
+	}
+}
+
+// renderError writes the pending error, if any and nothing was written yet.
+// Middleware that inspects the response after c.Next (e.g. Idempotency)
+// calls it itself, since Errors only runs once the whole chain returns.
+func renderError(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	writeProblem(c, apperr.From(c.Errors.Last().Err))
+}
+
+// Recovery turns a panic into a logged 500 problem response.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		err := fmt.Errorf("panic: %v", recovered)
+		logging.FromContext(c.Request.Context()).Error("request panicked", "error", err, "stack", string(debug.Stack()))
+		writeProblem(c, apperr.Internal(err))
+		c.Abort()
+	})
+}
+
+// NotFound answers requests that match no route, or match a route only
+// with a different method.
+func NotFound(c *gin.Context) {
+	c.Error(apperr.NotFound(fmt.Sprintf("no route for %s %s", c.Request.Method, c.Request.URL.Path)))
+}
+
+func writeProblem(c *gin.Context, err *apperr.Error) {
+	problem := err.Problem(c.Request.URL.Path)
+	problem.RequestID = reqctx.MetaFrom(c.Request.Context()).RequestID
+
+	if err.Kind == apperr.KindUnavailable && c.Writer.Header().Get("Retry-After") == "" {
+		c.Header("Retry-After", "1")
+	}
+	c.Header("Content-Type", apperr.ProblemContentType)
+	c.JSON(problem.Status, problem)
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyLockTimeout bounds how long a crashed request can block
+	// retries that reuse its key.
+	idempotencyLockTimeout = time.Minute
+)
+
+// Idempotency makes mutating requests that carry an Idempotency-Key safe to
+// retry. The first request with a key runs normally and its response is stored
+// for ttl; retries with the same key and body get the stored response back,
+// retries with a different body get 422, and retries while the first request is
+// still running get 409. Keys are scoped per user (or per client IP on public
+// routes), so this must run after AuthMiddleware where that applies. The body
+// is hashed whole, so it must also run after BodyLimit: the route's body limit,
+// a statement upload's included, is the only cap on what is read here.
+func Idempotency(store repository.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !isMutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.Error(apperr.Validation("Idempotency-Key is too long", apperr.Field(IdempotencyKeyHeader, fmt.Sprintf("must be at most %d characters long", maxIdempotencyKeyLength))))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.Error(apperr.New(apperr.KindPayloadTooLarge, "request body too large"))
+			} else {
+				c.Error(apperr.Validation("request body could not be read"))
+			}
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &model.IdempotencyRecord{
+			Scope:       idempotencyScope(c),
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: requestHash(c.Request.Method, c.Request.URL.Path, body),
+		}
+
+		existing, err := store.ReserveIdempotencyKey(c.Request.Context(), record, idempotencyLockTimeout)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("failed to reserve idempotency key", "scope", record.Scope, "error", err)
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		if existing != nil {
+			replayIdempotent(c, record, existing)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		// render a pending error now so the recorded response is the real one
+		renderError(c)
+
+		// the request context may already be cancelled once the client is gone
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), 5*time.Second)
+		defer cancel()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			// server errors are not final, let the client retry with the same key
+			if err := store.ReleaseIdempotencyKey(ctx, record.Scope, record.Key); err != nil {
+				logging.FromContext(c.Request.Context()).Error("failed to release idempotency key", "scope", record.Scope, "error", err)
+			}
+			return
+		}
+		err = store.CompleteIdempotencyKey(ctx, record.Scope, record.Key, status, recorder.Header().Get("Content-Type"),
+			replayedHeaders(recorder.Header()), recorder.body.Bytes(), ttl)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("failed to store idempotent response", "scope", record.Scope, "error", err)
+		}
+	}
+}
+
+func replayIdempotent(c *gin.Context, record, existing *model.IdempotencyRecord) {
+	switch {
+	case existing.RequestHash != record.RequestHash:
+		logging.FromContext(c.Request.Context()).Warn("idempotency key reused with a different request", "scope", record.Scope)
+		c.Error(apperr.New(apperr.KindUnprocessable, "Idempotency-Key was already used for a different request"))
+		c.Abort()
+	case existing.StatusCode == nil:
+		c.Error(apperr.Conflict("a request with this Idempotency-Key is still being processed"))
+		c.Abort()
+	default:
+		// replace rather than add to what middleware already set, like Deprecation
+		for name, values := range existing.ResponseHeaders {
+			c.Writer.Header().Del(name)
+			for _, value := range values {
+				c.Writer.Header().Add(name, value)
+			}
+		}
+		c.Header(IdempotencyReplayedHeader, "true")
+		c.Data(*existing.StatusCode, existing.ContentType, existing.ResponseBody)
+		c.Abort()
+	}
+}
+
+// replayedResponseHeaders describe the response itself, so a replay repeats
+// them. Headers about this particular request, like X-Request-ID and the
+// rate limit, are left to be set afresh.
+var replayedResponseHeaders = []string{
+	"ETag",
+	"Location",
+	"Content-Location",
+	"Last-Modified",
+	"Deprecation",
+	"Sunset",
+	"Link",
+}
+
+func replayedHeaders(header http.Header) map[string][]string {
+	kept := map[string][]string{}
+	for _, name := range replayedResponseHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			kept[name] = values
+		}
+	}
+	return kept
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func idempotencyScope(c *gin.Context) string {
+	if userID, ok := c.Get("user_id"); ok {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", method, path)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder tees everything written to the client into body.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/logging"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI checks traffic against doc. With cfg.ValidateRequests, requests
+// that don't match are rejected with a 400 (415 for an unsupported body type)
+// before they reach the handler. Authentication is left to AuthMiddleware, so
+// this should run after it. With cfg.ValidateResponses, mismatching responses
+// are logged as errors; that keeps a copy of every body, so it is meant for
+// test environments.
+func OpenAPI(doc *openapi3.T, cfg config.OpenAPIConfig) (gin.HandlerFunc, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("build openapi router: %w", err)
+	}
+	options := &openapi3filter.Options{
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		IncludeResponseStatus: true,
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			// every route is documented (checked at startup), so this is a
+			// request the router will answer with 404 anyway
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if cfg.ValidateRequests {
+			if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+				logging.FromContext(c.Request.Context()).Warn("request does not match the openapi spec", "error", err)
+				c.Error(openAPIRequestError(err))
+				c.Abort()
+				return
+			}
+		}
+		if !cfg.ValidateResponses {
+			c.Next()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		renderError(c)
+
+		response := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 recorder.Status(),
+			Header:                 recorder.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(recorder.body.Bytes())),
+			Options:                options,
+		}
+		if err := openapi3filter.ValidateResponse(c.Request.Context(), response); err != nil {
+			logging.FromContext(c.Request.Context()).Error("response does not match the openapi spec",
+				"route", c.Request.Method+" "+c.FullPath(), "status", recorder.Status(), "error", err)
+		}
+	}, nil
+}
+
+// openAPIRequestError names the offending parameter or body field.
+func openAPIRequestError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return apperr.New(apperr.KindPayloadTooLarge, "request body too large")
+	}
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return apperr.Validation("request does not match the API specification")
+	}
+	if reqErr.RequestBody != nil && strings.HasPrefix(reqErr.Reason, "header Content-Type has unexpected value") {
+		return apperr.New(apperr.KindUnsupportedMediaType, "unsupported request content type")
+	}
+
+	field, message := "body", reqErr.Reason
+	if reqErr.Parameter != nil {
+		field = reqErr.Parameter.Name
+	}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(reqErr.Err, &schemaErr) {
+		if path := jsonPath(schemaErr.JSONPointer()); path != "" {
+			field = path
+			if reqErr.Parameter != nil {
+				field = reqErr.Parameter.Name + "." + path
+			}
+		}
+		message = schemaErr.Reason
+	} else if message == "" && reqErr.Err != nil {
+		message = reqErr.Err.Error()
+	}
+
+	if reqErr.Parameter != nil {
+		return apperr.Validation("invalid "+reqErr.Parameter.In+" parameter", apperr.Field(field, message))
+	}
+	if reqErr.RequestBody != nil && reqErr.Err != nil && errors.Is(reqErr.Err, openapi3filter.ErrInvalidRequired) {
+		return apperr.Validation("request body is required")
+	}
+	return apperr.Validation("request body failed validation", apperr.Field(field, message))
+}
+
+// jsonPath renders a JSON pointer the way bind errors name fields, e.g.
+// operations[0].op.
+func jsonPath(pointer []string) string {
+	var b strings.Builder
+	for _, segment := range pointer {
+		if _, err := strconv.Atoi(segment); err == nil {
+			b.WriteString("[" + segment + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/ratelimit"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit enforces policy per authenticated user, or per client IP when the
+// request has no user. It must run after AuthMiddleware to key by user. If the
+// limiter backend fails the request is let through rather than taking the API
+// down with it.
+func RateLimit(limiter ratelimit.Limiter, policy ratelimit.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if userID, ok := c.Get("user_id"); ok {
+			key = fmt.Sprintf("user:%v", userID)
+		}
+
+		res, err := limiter.Allow(c.Request.Context(), key, policy)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("rate limiter failed, allowing request", "policy", policy.Name, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(res.Reset))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%s", policy.Requests, ceilSeconds(policy.Per)))
+
+		if !res.Allowed {
+			logging.FromContext(c.Request.Context()).Warn("rate limit exceeded", "policy", policy.Name, "key", key)
+			c.Header("Retry-After", ceilSeconds(res.RetryAfter))
+			c.Error(apperr.New(apperr.KindTooManyRequests, "too many requests"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"expense-tracker/internal/db"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadYourWrites pins a user's reads to the primary database for window after
+// each of their successful writes, so a client never misses its own change
+// because the replica has not replayed it yet. Clients can also ask for it on
+// any request with "X-Read-Consistency: strong". It must run after
+// AuthMiddleware.
+//
+// Recent writes are remembered per instance; behind a load balancer without
+// sticky sessions clients that need it should send the header.
+func ReadYourWrites(window time.Duration) gin.HandlerFunc {
+	var (
+		mu        sync.Mutex
+		lastWrite = map[int]time.Time{}
+		lastSweep time.Time
+	)
+
+	return func(c *gin.Context) {
+		userID, ok := c.Get("user_id")
+		id, isInt := userID.(int)
+		if !ok || !isInt {
+			c.Next()
+			return
+		}
+
+		mu.Lock()
+		recent := time.Since(lastWrite[id]) < window
+		mu.Unlock()
+
+		if recent || strings.EqualFold(c.GetHeader("X-Read-Consistency"), "strong") {
+			c.Request = c.Request.WithContext(db.WithPrimary(c.Request.Context()))
+		}
+
+		c.Next()
+
+		if !isMutating(c.Request.Method) || c.GetBool(readOnlyKey) || len(c.Errors) > 0 || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		now := time.Now()
+		mu.Lock()
+		defer mu.Unlock()
+		lastWrite[id] = now
+		// drop users whose window has passed, at most once per window
+		if now.Sub(lastSweep) >= window {
+			for user, at := range lastWrite {
+				if now.Sub(at) >= window {
+					delete(lastWrite, user)
+				}
+			}
+			lastSweep = now
+		}
+	}
+}
+
+const readOnlyKey = "read_only"
+
+// ReadOnly marks a route that uses a mutating method without writing, such as
+// POST /graphql, so ReadYourWrites does not pin the user to the primary.
+func ReadOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(readOnlyKey, true)
+		c.Next()
+	}
+}
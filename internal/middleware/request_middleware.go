@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"expense-tracker/internal/logging"
+	"expense-tracker/internal/reqctx"
+	"expense-tracker/internal/tracing"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestContext assigns every request an ID (reusing the caller's X-Request-ID
+// when present), stores it with the client IP in the request context, attaches
+// a request-scoped logger and writes one access log line when the request ends.
+func RequestContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx := reqctx.WithMeta(c.Request.Context(), reqctx.Meta{
+			RequestID: requestID,
+			IP:        c.ClientIP(),
+		})
+		logger := logging.FromContext(ctx).With(
+			"request_id", requestID,
+			"method", c.Request.Method,
+			"route", route,
+		)
+		// the tracing middleware runs first, so the request span already exists
+		if traceID := tracing.TraceID(ctx); traceID != "" {
+			logger = logger.With("trace_id", traceID)
+		}
+		ctx = logging.WithLogger(ctx, logger)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		// read the logger back, AuthMiddleware may have added user_id to it
+		logging.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "request completed",
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// validRequestID accepts caller-supplied IDs that are short and printable, so
+// they can't be used to inject content into logs or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout puts a deadline on the request context: the route's entry in
+// overrides, keyed by "METHOD /path" pattern, or def otherwise. Handlers and
+// everything they call inherit it through c.Request.Context(). A zero
+// override leaves the route without a deadline, for long-lived streams.
+func Timeout(def time.Duration, overrides map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := def
+		if d, ok := overrides[c.Request.Method+" "+c.FullPath()]; ok {
+			timeout = d
+		}
+		if timeout == 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditEntityExpense = "expense"
+	AuditEntityUser    = "user"
+
+	AuditActionCreate       = "create"
+	AuditActionUpdate       = "update"
+	AuditActionDelete       = "delete"
+	AuditActionRestore      = "restore"
+	AuditActionPurge        = "purge"
+	AuditActionRecategorize = "recategorize"
+)
+
+// AuditEntry is one append-only record of a change. OwnerID is the user whose
+// data changed; ActorID is who changed it and is nil for system jobs.
+type AuditEntry struct {
+	ID         int64           `json:"id" db:"id"`
+	OwnerID    *int            `json:"owner_id,omitempty" db:"owner_id"`
+	ActorID    *int            `json:"actor_id,omitempty" db:"actor_id"`
+	Action     string          `json:"action" db:"action"`
+	EntityType string          `json:"entity_type" db:"entity_type"`
+	EntityID   int             `json:"entity_id" db:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty" db:"before"`
+	After      json.RawMessage `json:"after,omitempty" db:"after"`
+	RequestID  string          `json:"request_id,omitempty" db:"request_id"`
+	IP         string          `json:"ip,omitempty" db:"ip"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Budget is a user's monthly spending limit for one category. Months are
+// calendar months in UTC.
+type Budget struct {
+	Category     string    `json:"category" db:"category"`
+	MonthlyLimit float64   `json:"monthly_limit" db:"monthly_limit"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -3,9 +3,17 @@ package model
 import "time"
 
 type Expense struct {
-	ID        int       `json:"id" db:"id"`
-	UserID    int       `json:"user_id" db:"user_id"`
-	Amount    float64   `json:"amount" db:"amount"`
-	Category  string    `json:"category" db:"category"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID        int        `json:"id" db:"id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	Amount    float64    `json:"amount" db:"amount"`
+	Category  string     `json:"category" db:"category"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	Version   int        `json:"version" db:"version"`
+
+	// Description is free text such as the merchant; rules match on it and
+	// may set the tags and the reimbursable flag.
+	Description  string   `json:"description" db:"description"`
+	Tags         []string `json:"tags" db:"tags"`
+	Reimbursable bool     `json:"reimbursable" db:"reimbursable"`
 }
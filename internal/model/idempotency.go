@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// IdempotencyRecord remembers the response to a request sent with an
+// Idempotency-Key. StatusCode is nil while the first request is still running.
+// ResponseHeaders are the response headers a replay repeats besides the
+// content type.
+type IdempotencyRecord struct {
+	Scope           string              `db:"scope"`
+	Key             string              `db:"key"`
+	Method          string              `db:"method"`
+	Path            string              `db:"path"`
+	RequestHash     string              `db:"request_hash"`
+	StatusCode      *int                `db:"status_code"`
+	ContentType     string              `db:"content_type"`
+	ResponseHeaders map[string][]string `db:"response_headers"`
+	ResponseBody    []byte              `db:"response_body"`
+	CreatedAt       time.Time           `db:"created_at"`
+	ExpiresAt       time.Time           `db:"expires_at"`
+}
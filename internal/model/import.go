@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// StatementImport is an uploaded bank statement. The counts cover its
+// candidates by status.
+type StatementImport struct {
+	ID        int       `json:"id" db:"id"`
+	Filename  string    `json:"filename" db:"filename"`
+	Format    string    `json:"format" db:"format"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	Pending   int       `json:"pending" db:"pending"`
+	Accepted  int       `json:"accepted" db:"accepted"`
+	Discarded int       `json:"discarded" db:"discarded"`
+}
+
+const (
+	CandidatePending   = "pending"
+	CandidateAccepted  = "accepted"
+	CandidateDiscarded = "discarded"
+)
+
+// ImportCandidate is a debit from a statement that becomes an expense once
+// the user accepts it. Amount is what was spent, so always positive.
+// ExpenseID is set once accepted, unless the expense was purged since.
+type ImportCandidate struct {
+	ID         int64      `json:"id" db:"id"`
+	ImportID   int        `json:"import_id" db:"import_id"`
+	Account    string     `json:"account" db:"account"`
+	FITID      string     `json:"fitid" db:"fitid"`
+	PostedAt   time.Time  `json:"posted_at" db:"posted_at"`
+	Amount     float64    `json:"amount" db:"amount"`
+	Currency   string     `json:"currency,omitempty" db:"currency"`
+	Payee      string     `json:"payee" db:"payee"`
+	Memo       string     `json:"memo,omitempty" db:"memo"`
+	Category   *string    `json:"category" db:"category"`
+	Status     string     `json:"status" db:"status"`
+	ExpenseID  *int       `json:"expense_id,omitempty" db:"expense_id"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// Rule files expenses automatically. It matches an expense when every
+// condition that is set holds; weekdays are lowercase English day names,
+// taken in UTC. On a match it applies every action that is set.
+type Rule struct {
+	ID       int    `json:"id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	Priority int    `json:"priority" db:"priority"`
+	Enabled  bool   `json:"enabled" db:"enabled"`
+
+	DescriptionContains *string  `json:"description_contains,omitempty" db:"description_contains"`
+	DescriptionRegex    *string  `json:"description_regex,omitempty" db:"description_regex"`
+	MinAmount           *float64 `json:"min_amount,omitempty" db:"min_amount"`
+	MaxAmount           *float64 `json:"max_amount,omitempty" db:"max_amount"`
+	Weekdays            []string `json:"weekdays" db:"weekdays"`
+
+	SetCategory      *string  `json:"set_category,omitempty" db:"set_category"`
+	AddTags          []string `json:"add_tags" db:"add_tags"`
+	MarkReimbursable bool     `json:"mark_reimbursable" db:"mark_reimbursable"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RuleChange is what applying the rules does, or would do, to an expense.
+type RuleChange struct {
+	ExpenseID int           `json:"expense_id"`
+	Before    ExpenseLabels `json:"before"`
+	After     ExpenseLabels `json:"after"`
+	RuleIDs   []int         `json:"rule_ids"`
+}
+
+// ExpenseLabels are the fields of an expense rules can set.
+type ExpenseLabels struct {
+	Category     string   `json:"category"`
+	Tags         []string `json:"tags"`
+	Reimbursable bool     `json:"reimbursable"`
+}
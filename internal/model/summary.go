@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// ExpenseSummary aggregates a set of expenses. Min and Max are nil when the
+// set is empty.
+type ExpenseSummary struct {
+	Count int      `json:"count"`
+	Total float64  `json:"total"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+// CategoryTotal aggregates a user's expenses in one category.
+type CategoryTotal struct {
+	Category string  `json:"category"`
+	Count    int     `json:"count"`
+	Total    float64 `json:"total"`
+}
+
+// MonthlyTotal aggregates a user's expenses created in one calendar month
+// (UTC). Month is the first instant of the month.
+type MonthlyTotal struct {
+	Month time.Time `json:"month"`
+	Count int       `json:"count"`
+	Total float64   `json:"total"`
+}
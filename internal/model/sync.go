@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// SyncExpense is an expense as a syncing client sees it. Deleted marks a
+// tombstone: the expense is in the trash, or gone for good, in which case
+// only UUID, ID and UpdatedAt are set.
+type SyncExpense struct {
+	UUID         string     `json:"uuid"`
+	ID           int        `json:"id"`
+	Amount       float64    `json:"amount,omitempty"`
+	Category     string     `json:"category,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	Reimbursable bool       `json:"reimbursable,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Version      int        `json:"version,omitempty"`
+	Deleted      bool       `json:"deleted"`
+	ChangeSeq    int64      `json:"-"`
+}
+
+// SyncApplied acknowledges a client change that took effect, or had already.
+type SyncApplied struct {
+	UUID    string `json:"uuid"`
+	ID      int    `json:"id,omitempty"`
+	Version int    `json:"version,omitempty"`
+}
+
+// Reasons a client change is reported back instead of applied.
+const (
+	SyncConflictVersion  = "version_mismatch"
+	SyncConflictDeleted  = "deleted"
+	SyncConflictNotFound = "not_found"
+	SyncConflictExists   = "exists"
+)
+
+// SyncConflict is a client change that was not applied. Server is the
+// expense as stored, when there is one.
+type SyncConflict struct {
+	UUID   string       `json:"uuid"`
+	Reason string       `json:"reason"`
+	Server *SyncExpense `json:"server,omitempty"`
+}
@@ -5,6 +5,6 @@ import "time"
 type User struct {
 	ID           int64     `json:"id" db:"id"`
 	Email        string    `json:"email" db:"email"`
-	PasswordHash string    `json:"_" db:"password_hash"`
+	PasswordHash string    `json:"-" db:"password_hash"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
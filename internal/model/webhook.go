@@ -0,0 +1,79 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types a webhook endpoint can subscribe to.
+const (
+	EventExpenseCreated  = "expense.created"
+	EventExpenseUpdated  = "expense.updated"
+	EventExpenseDeleted  = "expense.deleted"
+	EventExpenseRestored = "expense.restored"
+	EventBudgetExceeded  = "budget.exceeded"
+)
+
+// EventTypes lists every event type, in the order they are documented.
+var EventTypes = []string{
+	EventExpenseCreated,
+	EventExpenseUpdated,
+	EventExpenseDeleted,
+	EventExpenseRestored,
+	EventBudgetExceeded,
+}
+
+// OutboxEvent is a change waiting to be fanned out to webhook deliveries.
+type OutboxEvent struct {
+	ID        int64           `json:"id" db:"id"`
+	UserID    int             `json:"-" db:"user_id"`
+	Type      string          `json:"type" db:"event_type"`
+	Payload   json.RawMessage `json:"data" db:"payload"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+	// Seq is the event's position in its user's log. A user's events commit
+	// in seq order, which ids do not follow, so streams resume by it.
+	Seq int64 `json:"seq,omitempty" db:"seq"`
+}
+
+// WebhookEndpoint receives the events it subscribes to. Secret signs every
+// delivery and is only shown when the endpoint is created.
+type WebhookEndpoint struct {
+	ID        int       `json:"id" db:"id"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"-" db:"secret"`
+	Events    []string  `json:"events" db:"events"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// DeliveryDead marks a delivery that ran out of attempts; only a manual
+	// retry sends it again.
+	DeliveryDead = "dead"
+)
+
+// WebhookDelivery is one event sent, or to be sent, to one endpoint.
+// NextAttemptAt is only set while the delivery is pending.
+type WebhookDelivery struct {
+	ID             int64      `json:"id" db:"id"`
+	EndpointID     int        `json:"endpoint_id" db:"endpoint_id"`
+	EventID        int64      `json:"event_id" db:"event_id"`
+	EventType      string     `json:"event_type" db:"event_type"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastStatusCode *int       `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// PendingDelivery is a claimed delivery with everything needed to send it.
+type PendingDelivery struct {
+	ID       int64
+	Attempts int
+	URL      string
+	Secret   string
+	Event    OutboxEvent
+}
@@ -0,0 +1,98 @@
+// Package openapi holds the API's OpenAPI 3 document. It serves the document
+// and a Swagger UI page for it, and checks that the router and the document
+// describe the same routes.
+package openapi
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// Both paths are relative to the group the document is registered on.
+const (
+	// SpecPath serves the document as JSON.
+	SpecPath = "/openapi.json"
+	// DocsPath serves Swagger UI; its assets live below it.
+	DocsPath = "/docs"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+//go:embed docs.html
+var docsPage []byte
+
+// Load parses and validates the embedded document.
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("parse openapi.yaml: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid openapi.yaml: %w", err)
+	}
+	return doc, nil
+}
+
+// Register serves doc at SpecPath and Swagger UI at DocsPath below router,
+// usually the group of the API version doc describes.
+func Register(router gin.IRoutes, doc *openapi3.T) error {
+	body, err := doc.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("encode openapi document: %w", err)
+	}
+	assets := http.FS(swaggerFiles.FS)
+
+	router.GET(SpecPath, func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", body)
+	})
+	router.GET(DocsPath+"/*filepath", func(c *gin.Context) {
+		file := c.Param("filepath")
+		if file == "/" || file == "/index.html" {
+			c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+			return
+		}
+		c.FileFromFS(file, assets)
+	})
+	return nil
+}
+
+var ginParam = regexp.MustCompile(`:([^/]+)`)
+
+// Mismatches lists routes registered on the router that doc does not
+// describe, and operations in doc that no route serves. Swagger UI's own
+// routes are not part of the API and are skipped.
+func Mismatches(doc *openapi3.T, routes gin.RoutesInfo) []string {
+	var problems []string
+	routed := map[string]bool{}
+	for _, route := range routes {
+		if strings.HasSuffix(route.Path, DocsPath+"/*filepath") {
+			continue
+		}
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		routed[route.Method+" "+path] = true
+
+		item := doc.Paths.Value(path)
+		if item == nil || item.GetOperation(route.Method) == nil {
+			problems = append(problems, fmt.Sprintf("%s %s is routed but not documented", route.Method, route.Path))
+		}
+	}
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			if !routed[method+" "+path] {
+				problems = append(problems, fmt.Sprintf("%s %s is documented but not routed", method, path))
+			}
+		}
+	}
+	slices.Sort(problems)
+	return problems
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresLimiter keeps buckets in the rate_limit_buckets table so every
+// replica shares the same limits. Each request is a single upsert that refills
+// and takes a token atomically under the row lock.
+type PostgresLimiter struct {
+	pool *pgxpool.Pool
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresLimiter(pool *pgxpool.Pool) *PostgresLimiter {
+	return &PostgresLimiter{pool: pool}
+}
+
+func (l *PostgresLimiter) Allow(ctx context.Context, key string, policy Policy) (Result, error) {
+	l.sweep()
+
+	// refill is repeated because SET expressions can only see the old row
+	const refill = "LEAST($2, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $3)"
+	query := `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $2 - 1, TRUE, NOW())
+		ON CONFLICT (key) DO UPDATE
+		SET tokens = ` + refill + ` - CASE WHEN ` + refill + ` >= 1 THEN 1 ELSE 0 END,
+			allowed = ` + refill + ` >= 1,
+			updated_at = NOW()
+		RETURNING tokens, allowed
+	`
+	var tokens float64
+	var allowed bool
+	err := l.pool.QueryRow(ctx, query, policy.Name+":"+key, float64(policy.Burst), policy.rate()).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(policy, allowed, tokens), nil
+}
+
+// sweep deletes idle buckets in the background at most once per idleBucketTTL.
+func (l *PostgresLimiter) sweep() {
+	l.mu.Lock()
+	if time.Since(l.lastSweep) < idleBucketTTL {
+		l.mu.Unlock()
+		return
+	}
+	l.lastSweep = time.Now()
+	l.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		query := `DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - make_interval(secs => $1)`
+		if _, err := l.pool.Exec(ctx, query, idleBucketTTL.Seconds()); err != nil {
+			slog.Error("failed to sweep rate limit buckets", "error", err)
+		}
+	}()
+}
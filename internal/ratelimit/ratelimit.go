@@ -0,0 +1,84 @@
+// Package ratelimit implements token-bucket rate limiting with pluggable
+// storage: an in-process store for single instances and a Postgres store
+// shared by every replica.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a token bucket that holds up to Burst tokens and refills at
+// Requests per Per. Every request takes one token.
+type Policy struct {
+	Name     string
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// ParsePolicy reads a spec of the form "<requests>/<duration>", e.g. "10/1m".
+// The burst equals the request count, so a client may spend the whole window
+// at once.
+func ParsePolicy(name, spec string) (Policy, error) {
+	count, per, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("rate limit %s: expected <requests>/<duration>, got %q", name, spec)
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || requests <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %s: invalid request count %q", name, count)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || period <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %s: invalid duration %q", name, per)
+	}
+	return Policy{Name: name, Requests: requests, Per: period, Burst: requests}, nil
+}
+
+// rate is the refill speed in tokens per second.
+func (p Policy) rate() float64 {
+	return float64(p.Requests) / p.Per.Seconds()
+}
+
+// Result describes the bucket after a request was counted against it.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next token is available; zero when allowed.
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	// Allow takes one token from the bucket identified by key under policy.
+	Allow(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// result turns the tokens left after a request into a Result.
+func result(policy Policy, allowed bool, tokens float64) Result {
+	rate := policy.rate()
+	res := Result{
+		Allowed:   allowed,
+		Limit:     policy.Burst,
+		Remaining: max(int(math.Floor(tokens)), 0),
+		Reset:     secondsToDuration((float64(policy.Burst) - tokens) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"expense-tracker/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type AuditRepository interface {
+	CreateAuditEntry(ctx context.Context, entry *model.AuditEntry) error
+	GetEntityHistory(ctx context.Context, entityType string, entityID, ownerID int) ([]*model.AuditEntry, error)
+	GetEntityHistories(ctx context.Context, entityType string, entityIDs []int, ownerID int) ([]*model.AuditEntry, error)
+	GetOwnerActivity(ctx context.Context, ownerID int, beforeID int64, limit int) ([]*model.AuditEntry, error)
+}
+
+type auditRepository struct {
+	db DBTX
+	// reader serves history and activity listings and may be a replica.
+	reader DBTX
+}
+
+func NewAuditRepository(db, reader DBTX) AuditRepository {
+	return &auditRepository{db: db, reader: reader}
+}
+
+func (r *auditRepository) CreateAuditEntry(ctx context.Context, entry *model.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (owner_id, actor_id, action, entity_type, entity_id, before, after, request_id, ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''))
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		entry.OwnerID,
+		entry.ActorID,
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		entry.Before,
+		entry.After,
+		entry.RequestID,
+		entry.IP,
+	).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// GetEntityHistory returns every change to one entity owned by ownerID, oldest first.
+func (r *auditRepository) GetEntityHistory(ctx context.Context, entityType string, entityID, ownerID int) ([]*model.AuditEntry, error) {
+	query := `
+			SELECT id, owner_id, actor_id, action, entity_type, entity_id, before, after,
+				COALESCE(request_id, ''), COALESCE(ip, ''), created_at
+			FROM audit_log
+			WHERE entity_type = $1 AND entity_id = $2 AND owner_id = $3
+			ORDER BY id ASC
+	`
+	rows, err := r.reader.Query(ctx, query, entityType, entityID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEntries(rows)
+}
+
+// GetEntityHistories is GetEntityHistory for several entities at once,
+// ordered by entity and then oldest first.
+func (r *auditRepository) GetEntityHistories(ctx context.Context, entityType string, entityIDs []int, ownerID int) ([]*model.AuditEntry, error) {
+	query := `
+			SELECT id, owner_id, actor_id, action, entity_type, entity_id, before, after,
+				COALESCE(request_id, ''), COALESCE(ip, ''), created_at
+			FROM audit_log
+			WHERE entity_type = $1 AND entity_id = ANY($2) AND owner_id = $3
+			ORDER BY entity_id, id ASC
+	`
+	rows, err := r.reader.Query(ctx, query, entityType, entityIDs, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEntries(rows)
+}
+
+// GetOwnerActivity returns the newest changes to ownerID's data with an id below
+// beforeID (0 for the first page), newest first.
+func (r *auditRepository) GetOwnerActivity(ctx context.Context, ownerID int, beforeID int64, limit int) ([]*model.AuditEntry, error) {
+	query := `
+			SELECT id, owner_id, actor_id, action, entity_type, entity_id, before, after,
+				COALESCE(request_id, ''), COALESCE(ip, ''), created_at
+			FROM audit_log
+			WHERE owner_id = $1 AND ($2 = 0 OR id < $2)
+			ORDER BY id DESC
+			LIMIT $3
+	`
+	rows, err := r.reader.Query(ctx, query, ownerID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEntries(rows)
+}
+
+func scanAuditEntries(rows pgx.Rows) ([]*model.AuditEntry, error) {
+	defer rows.Close()
+
+	var entries []*model.AuditEntry
+	for rows.Next() {
+		var entry model.AuditEntry
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.OwnerID,
+			&entry.ActorID,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&entry.Before,
+			&entry.After,
+			&entry.RequestID,
+			&entry.IP,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
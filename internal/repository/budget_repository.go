@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"expense-tracker/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type BudgetRepository interface {
+	ListBudgets(ctx context.Context, userID int) ([]*model.Budget, error)
+	GetBudget(ctx context.Context, userID int, category string) (*model.Budget, error)
+	UpsertBudget(ctx context.Context, userID int, category string, monthlyLimit float64) (*model.Budget, error)
+	DeleteBudget(ctx context.Context, userID int, category string) error
+}
+
+type budgetRepository struct {
+	db DBTX
+}
+
+func NewBudgetRepository(db DBTX) BudgetRepository {
+	return &budgetRepository{db: db}
+}
+
+func (r *budgetRepository) ListBudgets(ctx context.Context, userID int) ([]*model.Budget, error) {
+	query := `
+			SELECT category, monthly_limit, created_at, updated_at
+			FROM budgets
+			WHERE user_id = $1
+			ORDER BY category
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []*model.Budget
+	for rows.Next() {
+		var budget model.Budget
+		if err := rows.Scan(&budget.Category, &budget.MonthlyLimit, &budget.CreatedAt, &budget.UpdatedAt); err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, &budget)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// GetBudget returns pgx.ErrNoRows when the category has no budget.
+func (r *budgetRepository) GetBudget(ctx context.Context, userID int, category string) (*model.Budget, error) {
+	query := `
+			SELECT category, monthly_limit, created_at, updated_at
+			FROM budgets
+			WHERE user_id = $1 AND category = $2
+	`
+	var budget model.Budget
+	err := r.db.QueryRow(ctx, query, userID, category).Scan(&budget.Category, &budget.MonthlyLimit, &budget.CreatedAt, &budget.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (r *budgetRepository) UpsertBudget(ctx context.Context, userID int, category string, monthlyLimit float64) (*model.Budget, error) {
+	query := `
+		INSERT INTO budgets (user_id, category, monthly_limit)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, category) DO UPDATE
+		SET monthly_limit = EXCLUDED.monthly_limit,
+			updated_at = NOW()
+		RETURNING category, monthly_limit, created_at, updated_at
+	`
+	var budget model.Budget
+	err := r.db.QueryRow(ctx, query, userID, category, monthlyLimit).Scan(&budget.Category, &budget.MonthlyLimit, &budget.CreatedAt, &budget.UpdatedAt)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &budget, nil
+}
+
+// DeleteBudget returns pgx.ErrNoRows when the category has no budget.
+func (r *budgetRepository) DeleteBudget(ctx context.Context, userID int, category string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM budgets WHERE user_id = $1 AND category = $2`, userID, category)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
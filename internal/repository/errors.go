@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"errors"
+	"expense-tracker/internal/apperr"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// constraintFields names the input field behind each constraint the API can
+// trip, so violations are reported against that field.
+var constraintFields = map[string]apperr.FieldError{
+	"users_email_key":       apperr.Field("email", "is already registered"),
+	"expenses_amount_check": apperr.Field("amount", "must be greater than 0"),
+	"expenses_user_id_fkey": apperr.Field("user_id", "does not exist"),
+}
+
+// translateError maps Postgres errors caused by bad input to typed errors:
+// unique violations become conflicts, check and foreign key violations and
+// out-of-range values become validation errors. A statement cancelled by
+// statement_timeout is reported as a temporary overload. Anything else,
+// including pgx.ErrNoRows, is returned unchanged.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	var appErr *apperr.Error
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		appErr = apperr.Conflict("a record with the same value already exists")
+	case "23514", "23503": // check_violation, foreign_key_violation
+		appErr = apperr.Validation("invalid input")
+	case "22001", "22003", "22P02": // string too long, numeric out of range, invalid text
+		appErr = apperr.Validation("a value is out of range or malformed")
+	case "57014": // query_canceled
+		appErr = apperr.Unavailable("the database took too long to respond")
+	default:
+		return err
+	}
+	if field, ok := constraintFields[pgErr.ConstraintName]; ok {
+		appErr.Fields = []apperr.FieldError{field}
+	}
+	appErr.Err = err
+	return appErr
+}
@@ -2,48 +2,145 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"expense-tracker/internal/model"
 	"fmt"
-	"log/slog"
-
-	"github.com/jackc/pgx/v5/pgxpool"
+	"strings"
+	"time"
 )
 
 type ExpenseRepository interface {
 	CreateExpense(ctx context.Context, userID int, amount float64, category string) (*model.Expense, error)
+	CreateDetailedExpense(ctx context.Context, userID int, expense *model.Expense) (*model.Expense, error)
 	GetAllExpense(ctx context.Context, userID int) ([]*model.Expense, error)
 	GetExpenseByID(ctx context.Context, expenseID, userID int) (*model.Expense, error)
-	UpdateExpense(ctx context.Context, expenseID, userID int, amount float64, category string) (*model.Expense, error)
+	GetExpenseByIDForUpdate(ctx context.Context, expenseID, userID int) (*model.Expense, error)
+	UpdateExpense(ctx context.Context, expenseID, userID int, e *model.Expense) (*model.Expense, error)
+	UpdateExpenseLabels(ctx context.Context, expenseID, userID int, labels model.ExpenseLabels) (*model.Expense, error)
 	DeleteExpense(ctx context.Context, expenseID, userID int) error
+	GetDeletedExpenses(ctx context.Context, userID int) ([]*model.Expense, error)
+	RestoreExpense(ctx context.Context, expenseID, userID int) (before, after *model.Expense, err error)
+	PurgeDeletedExpenses(ctx context.Context, before time.Time) ([]*model.Expense, error)
+	RecategorizeExpenses(ctx context.Context, userID int, filter ExpenseFilter, category string) ([]RecategorizedExpense, error)
+	ListExpenses(ctx context.Context, userID int, filter ExpenseFilter, after *ExpenseCursor, limit int) ([]*model.Expense, error)
+	ListExpensesAfterID(ctx context.Context, userID, afterID, limit int) ([]*model.Expense, error)
+	CountExpenses(ctx context.Context, userID int, filter ExpenseFilter) (int, error)
+	SummarizeExpenses(ctx context.Context, userID int, filter ExpenseFilter) (*model.ExpenseSummary, error)
+	TotalsByCategory(ctx context.Context, userID int, filter ExpenseFilter, categories []string) ([]*model.CategoryTotal, error)
+	TotalsByMonth(ctx context.Context, userID int, filter ExpenseFilter) ([]*model.MonthlyTotal, error)
+	CategoriesByDescription(ctx context.Context, userID int, descriptions []string) (map[string]string, error)
+}
+
+// ExpenseCursor is a position in ListExpenses' newest-first order; a page
+// starts right after it.
+type ExpenseCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// ExpenseFilter narrows a query to a user's expenses matching every non-nil field.
+type ExpenseFilter struct {
+	Category  *string
+	MinAmount *float64
+	MaxAmount *float64
+	From      *time.Time
+	To        *time.Time
+}
+
+// IsEmpty reports whether the filter has no criteria at all.
+func (f ExpenseFilter) IsEmpty() bool {
+	return f.Category == nil && f.MinAmount == nil && f.MaxAmount == nil && f.From == nil && f.To == nil
+}
+
+// where appends the filter's conditions to the given clause, numbering
+// placeholders after the existing args.
+func (f ExpenseFilter) where(clause string, args []any) (string, []any) {
+	add := func(cond string, v any) {
+		args = append(args, v)
+		clause += fmt.Sprintf(" AND "+cond, len(args))
+	}
+	if f.Category != nil {
+		add("category = $%d", *f.Category)
+	}
+	if f.MinAmount != nil {
+		add("amount >= $%d", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		add("amount <= $%d", *f.MaxAmount)
+	}
+	if f.From != nil {
+		add("created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("created_at < $%d", *f.To)
+	}
+	return clause, args
 }
 
 type expenseRepository struct {
-	pool *pgxpool.Pool
+	db DBTX
+	// reader serves listings; it may be a replica, so only use it for
+	// reads that tolerate slight staleness.
+	reader DBTX
 }
 
-func NewExpenseRepository(pool *pgxpool.Pool) ExpenseRepository {
-	return &expenseRepository{pool: pool}
+func NewExpenseRepository(db, reader DBTX) ExpenseRepository {
+	return &expenseRepository{db: db, reader: reader}
 }
 
 func (r *expenseRepository) CreateExpense(ctx context.Context, userID int, amount float64, category string) (*model.Expense, error) {
 	query := `
 		INSERT INTO expenses(user_id, amount, category)
 		VALUES($1,$2,$3)
-		RETURNING id, user_id, amount, category, created_at
+		RETURNING id, user_id, amount, category, created_at, version, description, tags, reimbursable
 	`
 
 	var expense model.Expense
 
-	err := r.pool.QueryRow(ctx, query, userID, amount, category).Scan(
+	err := r.db.QueryRow(ctx, query, userID, amount, category).Scan(
 		&expense.ID,
 		&expense.UserID,
 		&expense.Amount,
 		&expense.Category,
 		&expense.CreatedAt,
+		&expense.Version,
+		&expense.Description,
+		&expense.Tags,
+		&expense.Reimbursable,
 	)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
+	}
+	return &expense, nil
+}
+
+// CreateDetailedExpense stores expense's amount, category, description,
+// tags and reimbursable flag. A zero CreatedAt means now; set it for an
+// expense that happened earlier, such as one imported from a bank statement.
+func (r *expenseRepository) CreateDetailedExpense(ctx context.Context, userID int, e *model.Expense) (*model.Expense, error) {
+	query := `
+		INSERT INTO expenses(user_id, amount, category, description, tags, reimbursable, created_at)
+		VALUES($1,$2,$3,$4,$5,$6,COALESCE($7, NOW()))
+		RETURNING id, user_id, amount, category, created_at, version, description, tags, reimbursable
+	`
+	var createdAt *time.Time
+	if !e.CreatedAt.IsZero() {
+		createdAt = &e.CreatedAt
+	}
+	var expense model.Expense
+
+	err := r.db.QueryRow(ctx, query, userID, e.Amount, e.Category, e.Description, nonNilTags(e.Tags), e.Reimbursable, createdAt).Scan(
+		&expense.ID,
+		&expense.UserID,
+		&expense.Amount,
+		&expense.Category,
+		&expense.CreatedAt,
+		&expense.Version,
+		&expense.Description,
+		&expense.Tags,
+		&expense.Reimbursable,
+	)
+	if err != nil {
+		return nil, translateError(err)
 	}
 	return &expense, nil
 }
@@ -51,12 +148,12 @@ func (r *expenseRepository) CreateExpense(ctx context.Context, userID int, amoun
 func (r *expenseRepository) GetAllExpense(ctx context.Context, userID int) ([]*model.Expense, error) {
 
 	query := `
-			SELECT id, user_id, amount, created_at, category
+			SELECT id, user_id, amount, created_at, category, version, description, tags, reimbursable
 			FROM expenses
-			WHERE user_id = $1
+			WHERE user_id = $1 AND deleted_at IS NULL
 			ORDER BY created_at DESC
 	`
-	rows, err := r.pool.Query(ctx, query, userID)
+	rows, err := r.reader.Query(ctx, query, userID)
 
 	if err != nil {
 		return nil, err
@@ -72,6 +169,10 @@ func (r *expenseRepository) GetAllExpense(ctx context.Context, userID int) ([]*m
 			&expense.Amount,
 			&expense.CreatedAt,
 			&expense.Category,
+			&expense.Version,
+			&expense.Description,
+			&expense.Tags,
+			&expense.Reimbursable,
 		); err != nil {
 			return nil, err
 		}
@@ -85,18 +186,51 @@ func (r *expenseRepository) GetAllExpense(ctx context.Context, userID int) ([]*m
 
 func (r *expenseRepository) GetExpenseByID(ctx context.Context, expenseID, userID int) (*model.Expense, error) {
 	query := `
-			SELECT id, user_id, amount, category, created_at
+			SELECT id, user_id, amount, category, created_at, version, description, tags, reimbursable
+			FROM expenses
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			`
+	var expense model.Expense
+
+	err := r.db.QueryRow(ctx, query, expenseID, userID).Scan(
+		&expense.ID,
+		&expense.UserID,
+		&expense.Amount,
+		&expense.Category,
+		&expense.CreatedAt,
+		&expense.Version,
+		&expense.Description,
+		&expense.Tags,
+		&expense.Reimbursable,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// GetExpenseByIDForUpdate is GetExpenseByID with a row lock held until the
+// surrounding transaction ends; outside a transaction the lock is released immediately.
+func (r *expenseRepository) GetExpenseByIDForUpdate(ctx context.Context, expenseID, userID int) (*model.Expense, error) {
+	query := `
+			SELECT id, user_id, amount, category, created_at, version, description, tags, reimbursable
 			FROM expenses
-			WHERE id = $1 AND user_id = $2
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			FOR UPDATE
 			`
 	var expense model.Expense
 
-	err := r.pool.QueryRow(ctx, query, expenseID, userID).Scan(
+	err := r.db.QueryRow(ctx, query, expenseID, userID).Scan(
 		&expense.ID,
 		&expense.UserID,
 		&expense.Amount,
 		&expense.Category,
 		&expense.CreatedAt,
+		&expense.Version,
+		&expense.Description,
+		&expense.Tags,
+		&expense.Reimbursable,
 	)
 
 	if err != nil {
@@ -105,39 +239,479 @@ func (r *expenseRepository) GetExpenseByID(ctx context.Context, expenseID, userI
 	return &expense, nil
 }
 
-func (r *expenseRepository) UpdateExpense(ctx context.Context, expenseID, userID int, amount float64, category string) (*model.Expense, error) {
+// UpdateExpense writes e's amount, category, description, tags and
+// reimbursable flag.
+func (r *expenseRepository) UpdateExpense(ctx context.Context, expenseID, userID int, e *model.Expense) (*model.Expense, error) {
 	query := `
 			UPDATE expenses
-			SET amount = $1, category = $2
-			WHERE id = $3 AND user_id = $4
-			RETURNING id, user_id, amount, category, created_at
+			SET amount = $1, category = $2, description = $3, tags = $4, reimbursable = $5, version = version + 1
+			WHERE id = $6 AND user_id = $7 AND deleted_at IS NULL
+			RETURNING id, user_id, amount, category, created_at, version, description, tags, reimbursable
 	`
 	var expense model.Expense
 
-	err := r.pool.QueryRow(ctx, query, amount, category, expenseID, userID).Scan(
+	err := r.db.QueryRow(ctx, query, e.Amount, e.Category, e.Description, nonNilTags(e.Tags), e.Reimbursable, expenseID, userID).Scan(
 		// should be as per model struct whenever you are returning
 		&expense.ID,
 		&expense.UserID,
 		&expense.Amount,
 		&expense.Category,
 		&expense.CreatedAt,
+		&expense.Version,
+		&expense.Description,
+		&expense.Tags,
+		&expense.Reimbursable,
 	)
 
 	if err != nil {
-		slog.Error("UpdateExpense query failed", "expenseID", expenseID, "userID", userID, "error", err)
-		return nil, errors.New("failed to update expense")
+		return nil, translateError(err)
+	}
+	return &expense, nil
+}
+
+// UpdateExpenseLabels sets the fields categorization rules manage.
+func (r *expenseRepository) UpdateExpenseLabels(ctx context.Context, expenseID, userID int, labels model.ExpenseLabels) (*model.Expense, error) {
+	query := `
+			UPDATE expenses
+			SET category = $1, tags = $2, reimbursable = $3, version = version + 1
+			WHERE id = $4 AND user_id = $5 AND deleted_at IS NULL
+			RETURNING id, user_id, amount, category, created_at, version, description, tags, reimbursable
+	`
+	var expense model.Expense
+
+	err := r.db.QueryRow(ctx, query, labels.Category, nonNilTags(labels.Tags), labels.Reimbursable, expenseID, userID).Scan(
+		&expense.ID,
+		&expense.UserID,
+		&expense.Amount,
+		&expense.Category,
+		&expense.CreatedAt,
+		&expense.Version,
+		&expense.Description,
+		&expense.Tags,
+		&expense.Reimbursable,
+	)
+	if err != nil {
+		return nil, translateError(err)
 	}
 	return &expense, nil
 }
 
+// DeleteExpense moves the expense to the trash; PurgeDeletedExpenses removes it for good.
 func (r *expenseRepository) DeleteExpense(ctx context.Context, expenseID, userID int) error {
 	query := `
-			DELETE FROM expenses
-			WHERE id = $1 AND user_id = $2
+			UPDATE expenses
+			SET deleted_at = NOW(), version = version + 1
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 	`
-	_, err := r.pool.Exec(ctx, query, expenseID, userID)
+	_, err := r.db.Exec(ctx, query, expenseID, userID)
 	if err != nil {
-		return fmt.Errorf("unable to delete expense %w", err)
+		return fmt.Errorf("unable to delete expense: %w", translateError(err))
 	}
 	return nil
 }
+
+func (r *expenseRepository) GetDeletedExpenses(ctx context.Context, userID int) ([]*model.Expense, error) {
+	query := `
+			SELECT id, user_id, amount, category, created_at, deleted_at, version, description, tags, reimbursable
+			FROM expenses
+			WHERE user_id = $1 AND deleted_at IS NOT NULL
+			ORDER BY deleted_at DESC
+	`
+	rows, err := r.reader.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []*model.Expense
+	for rows.Next() {
+		var expense model.Expense
+		if err := rows.Scan(
+			&expense.ID,
+			&expense.UserID,
+			&expense.Amount,
+			&expense.Category,
+			&expense.CreatedAt,
+			&expense.DeletedAt,
+			&expense.Version,
+			&expense.Description,
+			&expense.Tags,
+			&expense.Reimbursable,
+		); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, &expense)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return expenses, nil
+}
+
+// RestoreExpense takes the expense out of the trash and returns it as it was
+// in the trash and as it is now.
+func (r *expenseRepository) RestoreExpense(ctx context.Context, expenseID, userID int) (*model.Expense, *model.Expense, error) {
+	query := `
+			WITH trashed AS (
+				SELECT id, deleted_at
+				FROM expenses
+				WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+				FOR UPDATE
+			)
+			UPDATE expenses e
+			SET deleted_at = NULL, version = e.version + 1
+			FROM trashed
+			WHERE e.id = trashed.id
+			RETURNING e.id, e.user_id, e.amount, e.category, e.created_at, e.version, e.description, e.tags, e.reimbursable, trashed.deleted_at
+	`
+	var after model.Expense
+	var deletedAt time.Time
+
+	err := r.db.QueryRow(ctx, query, expenseID, userID).Scan(
+		&after.ID,
+		&after.UserID,
+		&after.Amount,
+		&after.Category,
+		&after.CreatedAt,
+		&after.Version,
+		&after.Description,
+		&after.Tags,
+		&after.Reimbursable,
+		&deletedAt,
+	)
+	if err != nil {
+		return nil, nil, translateError(err)
+	}
+	before := after
+	before.Version = after.Version - 1
+	before.DeletedAt = &deletedAt
+	return &before, &after, nil
+}
+
+// PurgeDeletedExpenses permanently removes every expense trashed before the
+// given time and returns the removed rows.
+func (r *expenseRepository) PurgeDeletedExpenses(ctx context.Context, before time.Time) ([]*model.Expense, error) {
+	query := `
+			DELETE FROM expenses
+			WHERE deleted_at IS NOT NULL AND deleted_at < $1
+			RETURNING id, user_id, amount, category, created_at, deleted_at, version, description, tags, reimbursable
+	`
+	rows, err := r.db.Query(ctx, query, before)
+	if err != nil {
+		return nil, fmt.Errorf("unable to purge deleted expenses %w", err)
+	}
+	defer rows.Close()
+
+	var expenses []*model.Expense
+	for rows.Next() {
+		var expense model.Expense
+		if err := rows.Scan(
+			&expense.ID,
+			&expense.UserID,
+			&expense.Amount,
+			&expense.Category,
+			&expense.CreatedAt,
+			&expense.DeletedAt,
+			&expense.Version,
+			&expense.Description,
+			&expense.Tags,
+			&expense.Reimbursable,
+		); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, &expense)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to purge deleted expenses %w", err)
+	}
+	return expenses, nil
+}
+
+// RecategorizedExpense is one row changed by RecategorizeExpenses.
+type RecategorizedExpense struct {
+	Before *model.Expense
+	After  *model.Expense
+}
+
+// RecategorizeExpenses moves every live expense of the user matching filter to
+// category and returns each changed row as it was before and after.
+func (r *expenseRepository) RecategorizeExpenses(ctx context.Context, userID int, filter ExpenseFilter, category string) ([]RecategorizedExpense, error) {
+	where, args := filter.where("user_id = $2 AND deleted_at IS NULL", []any{category, userID})
+	query := `
+			WITH matched AS (
+				SELECT id, category
+				FROM expenses
+				WHERE ` + where + `
+				FOR UPDATE
+			)
+			UPDATE expenses e
+			SET category = $1, version = e.version + 1
+			FROM matched
+			WHERE e.id = matched.id
+			RETURNING e.id, e.user_id, e.amount, matched.category, e.category, e.created_at, e.version, e.description, e.tags, e.reimbursable
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to recategorize expenses: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	var changes []RecategorizedExpense
+	for rows.Next() {
+		var after model.Expense
+		var oldCategory string
+		if err := rows.Scan(
+			&after.ID,
+			&after.UserID,
+			&after.Amount,
+			&oldCategory,
+			&after.Category,
+			&after.CreatedAt,
+			&after.Version,
+			&after.Description,
+			&after.Tags,
+			&after.Reimbursable,
+		); err != nil {
+			return nil, err
+		}
+		before := after
+		before.Category = oldCategory
+		before.Version = after.Version - 1
+		changes = append(changes, RecategorizedExpense{Before: &before, After: &after})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to recategorize expenses: %w", translateError(err))
+	}
+	return changes, nil
+}
+
+// ListExpenses returns up to limit live expenses of the user matching filter,
+// newest first, starting after the cursor when one is given.
+func (r *expenseRepository) ListExpenses(ctx context.Context, userID int, filter ExpenseFilter, after *ExpenseCursor, limit int) ([]*model.Expense, error) {
+	where, args := filter.where("user_id = $1 AND deleted_at IS NULL", []any{userID})
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		where += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+	args = append(args, limit)
+	query := `
+			SELECT id, user_id, amount, category, created_at, version, description, tags, reimbursable
+			FROM expenses
+			WHERE ` + where + `
+			ORDER BY created_at DESC, id DESC
+			LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := r.reader.Query(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var expenses []*model.Expense
+	for rows.Next() {
+		var expense model.Expense
+		if err := rows.Scan(
+			&expense.ID,
+			&expense.UserID,
+			&expense.Amount,
+			&expense.Category,
+			&expense.CreatedAt,
+			&expense.Version,
+			&expense.Description,
+			&expense.Tags,
+			&expense.Reimbursable,
+		); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, &expense)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return expenses, nil
+}
+
+// ListExpensesAfterID pages through the user's live expenses in id order,
+// starting after afterID.
+func (r *expenseRepository) ListExpensesAfterID(ctx context.Context, userID, afterID, limit int) ([]*model.Expense, error) {
+	query := `
+			SELECT id, user_id, amount, category, created_at, version, description, tags, reimbursable
+			FROM expenses
+			WHERE user_id = $1 AND deleted_at IS NULL AND id > $2
+			ORDER BY id
+			LIMIT $3
+	`
+	rows, err := r.reader.Query(ctx, query, userID, afterID, limit)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var expenses []*model.Expense
+	for rows.Next() {
+		var expense model.Expense
+		if err := rows.Scan(
+			&expense.ID,
+			&expense.UserID,
+			&expense.Amount,
+			&expense.Category,
+			&expense.CreatedAt,
+			&expense.Version,
+			&expense.Description,
+			&expense.Tags,
+			&expense.Reimbursable,
+		); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, &expense)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return expenses, nil
+}
+
+// CountExpenses counts the user's live expenses matching filter.
+func (r *expenseRepository) CountExpenses(ctx context.Context, userID int, filter ExpenseFilter) (int, error) {
+	where, args := filter.where("user_id = $1 AND deleted_at IS NULL", []any{userID})
+	query := `SELECT COUNT(*) FROM expenses WHERE ` + where
+
+	var count int
+	if err := r.reader.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return 0, translateError(err)
+	}
+	return count, nil
+}
+
+// SummarizeExpenses aggregates the user's live expenses matching filter.
+func (r *expenseRepository) SummarizeExpenses(ctx context.Context, userID int, filter ExpenseFilter) (*model.ExpenseSummary, error) {
+	where, args := filter.where("user_id = $1 AND deleted_at IS NULL", []any{userID})
+	query := `
+			SELECT COUNT(*), COALESCE(SUM(amount), 0), MIN(amount), MAX(amount)
+			FROM expenses
+			WHERE ` + where
+
+	var summary model.ExpenseSummary
+	err := r.reader.QueryRow(ctx, query, args...).Scan(&summary.Count, &summary.Total, &summary.Min, &summary.Max)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &summary, nil
+}
+
+// TotalsByCategory aggregates the user's live expenses matching filter per
+// category, largest total first. A non-nil categories limits the result to
+// those categories; ones without expenses are left out.
+func (r *expenseRepository) TotalsByCategory(ctx context.Context, userID int, filter ExpenseFilter, categories []string) ([]*model.CategoryTotal, error) {
+	where, args := filter.where("user_id = $1 AND deleted_at IS NULL", []any{userID})
+	if categories != nil {
+		args = append(args, categories)
+		where += fmt.Sprintf(" AND category = ANY($%d)", len(args))
+	}
+	query := `
+			SELECT category, COUNT(*), SUM(amount)
+			FROM expenses
+			WHERE ` + where + `
+			GROUP BY category
+			ORDER BY SUM(amount) DESC, category
+	`
+	rows, err := r.reader.Query(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var totals []*model.CategoryTotal
+	for rows.Next() {
+		var total model.CategoryTotal
+		if err := rows.Scan(&total.Category, &total.Count, &total.Total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, &total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return totals, nil
+}
+
+// TotalsByMonth aggregates the user's live expenses matching filter per
+// calendar month in UTC, oldest first.
+func (r *expenseRepository) TotalsByMonth(ctx context.Context, userID int, filter ExpenseFilter) ([]*model.MonthlyTotal, error) {
+	where, args := filter.where("user_id = $1 AND deleted_at IS NULL", []any{userID})
+	query := `
+			SELECT date_trunc('month', created_at, 'UTC') AS month, COUNT(*), SUM(amount)
+			FROM expenses
+			WHERE ` + where + `
+			GROUP BY month
+			ORDER BY month
+	`
+	rows, err := r.reader.Query(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer rows.Close()
+
+	var totals []*model.MonthlyTotal
+	for rows.Next() {
+		var total model.MonthlyTotal
+		if err := rows.Scan(&total.Month, &total.Count, &total.Total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, &total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return totals, nil
+}
+
+// CategoriesByDescription returns, for each description the user has filed
+// live expenses under, the category used most often, the latest breaking
+// ties. Descriptions compare case-insensitively; the map is keyed by the
+// lowercased description.
+func (r *expenseRepository) CategoriesByDescription(ctx context.Context, userID int, descriptions []string) (map[string]string, error) {
+	keys := make([]string, 0, len(descriptions))
+	for _, d := range descriptions {
+		if d != "" {
+			keys = append(keys, strings.ToLower(d))
+		}
+	}
+	categories := make(map[string]string)
+	if len(keys) == 0 {
+		return categories, nil
+	}
+
+	query := `
+			SELECT DISTINCT ON (lower(description)) lower(description), category
+			FROM expenses
+			WHERE user_id = $1 AND deleted_at IS NULL AND description <> '' AND lower(description) = ANY($2)
+			GROUP BY lower(description), category
+			ORDER BY lower(description), COUNT(*) DESC, MAX(created_at) DESC
+	`
+	rows, err := r.reader.Query(ctx, query, userID, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var description, category string
+		if err := rows.Scan(&description, &category); err != nil {
+			return nil, err
+		}
+		categories[description] = category
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// nonNilTags keeps a nil slice from being stored as NULL.
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
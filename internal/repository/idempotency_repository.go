@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expense-tracker/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type IdempotencyRepository interface {
+	ReserveIdempotencyKey(ctx context.Context, record *model.IdempotencyRecord, lockFor time.Duration) (*model.IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, contentType string, headers map[string][]string, body []byte, ttl time.Duration) error
+	ReleaseIdempotencyKey(ctx context.Context, scope, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db DBTX
+}
+
+func NewIdempotencyRepository(db DBTX) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// ReserveIdempotencyKey claims the key for lockFor. It returns nil when the key
+// was free (or its previous record had expired) and the caller now owns it;
+// otherwise it returns the record already stored under the key.
+func (r *idempotencyRepository) ReserveIdempotencyKey(ctx context.Context, record *model.IdempotencyRecord, lockFor time.Duration) (*model.IdempotencyRecord, error) {
+	query := `
+		INSERT INTO idempotency_keys (scope, key, method, path, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW() + make_interval(secs => $6))
+		ON CONFLICT (scope, key) DO UPDATE
+		SET method = EXCLUDED.method,
+			path = EXCLUDED.path,
+			request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			content_type = NULL,
+			response_headers = NULL,
+			response_body = NULL,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < NOW()
+		RETURNING key
+	`
+	var key string
+	err := r.db.QueryRow(ctx, query,
+		record.Scope,
+		record.Key,
+		record.Method,
+		record.Path,
+		record.RequestHash,
+		lockFor.Seconds(),
+	).Scan(&key)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	// someone else holds a live record for this key
+	query = `
+			SELECT scope, key, method, path, request_hash, status_code,
+				COALESCE(content_type, ''), response_headers, response_body, created_at, expires_at
+			FROM idempotency_keys
+			WHERE scope = $1 AND key = $2
+	`
+	var existing model.IdempotencyRecord
+	err = r.db.QueryRow(ctx, query, record.Scope, record.Key).Scan(
+		&existing.Scope,
+		&existing.Key,
+		&existing.Method,
+		&existing.Path,
+		&existing.RequestHash,
+		&existing.StatusCode,
+		&existing.ContentType,
+		&existing.ResponseHeaders,
+		&existing.ResponseBody,
+		&existing.CreatedAt,
+		&existing.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// CompleteIdempotencyKey stores the response and keeps it replayable for ttl.
+func (r *idempotencyRepository) CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, contentType string, headers map[string][]string, body []byte, ttl time.Duration) error {
+	query := `
+			UPDATE idempotency_keys
+			SET status_code = $3, content_type = $4, response_headers = $5, response_body = $6,
+				expires_at = NOW() + make_interval(secs => $7)
+			WHERE scope = $1 AND key = $2
+	`
+	_, err := r.db.Exec(ctx, query, scope, key, statusCode, contentType, headers, body, ttl.Seconds())
+	return err
+}
+
+// ReleaseIdempotencyKey drops an unfinished reservation so the client can retry.
+func (r *idempotencyRepository) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	query := `
+			DELETE FROM idempotency_keys
+			WHERE scope = $1 AND key = $2 AND status_code IS NULL
+	`
+	_, err := r.db.Exec(ctx, query, scope, key)
+	return err
+}
+
+func (r *idempotencyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	query := `
+			DELETE FROM idempotency_keys
+			WHERE expires_at < NOW()
+	`
+	tag, err := r.db.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
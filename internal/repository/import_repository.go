@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"expense-tracker/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ImportRepository stores uploaded bank statements and the candidate
+// expenses awaiting review.
+type ImportRepository interface {
+	CreateImport(ctx context.Context, userID int, filename, format string) (*model.StatementImport, error)
+	AddCandidate(ctx context.Context, userID int, candidate *model.ImportCandidate) (bool, error)
+	ListImports(ctx context.Context, userID int, beforeID int, limit int) ([]*model.StatementImport, error)
+	GetImport(ctx context.Context, importID, userID int) (*model.StatementImport, error)
+	ListCandidates(ctx context.Context, importID int, status string) ([]*model.ImportCandidate, error)
+	GetCandidateForUpdate(ctx context.Context, candidateID int64, importID, userID int) (*model.ImportCandidate, error)
+	UpdateCandidate(ctx context.Context, candidate *model.ImportCandidate) (*model.ImportCandidate, error)
+}
+
+type importRepository struct {
+	db DBTX
+}
+
+func NewImportRepository(db DBTX) ImportRepository {
+	return &importRepository{db: db}
+}
+
+func (r *importRepository) CreateImport(ctx context.Context, userID int, filename, format string) (*model.StatementImport, error) {
+	query := `
+		INSERT INTO statement_imports (user_id, filename, format)
+		VALUES ($1, $2, $3)
+		RETURNING id, filename, format, created_at
+	`
+	var imp model.StatementImport
+	err := r.db.QueryRow(ctx, query, userID, filename, format).Scan(&imp.ID, &imp.Filename, &imp.Format, &imp.CreatedAt)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &imp, nil
+}
+
+// AddCandidate stores a pending candidate and fills in its id. It reports
+// false, storing nothing, when the user already has a candidate for the same
+// account and FITID.
+func (r *importRepository) AddCandidate(ctx context.Context, userID int, c *model.ImportCandidate) (bool, error) {
+	query := `
+		INSERT INTO import_candidates (import_id, user_id, account, fitid, posted_at, amount, currency, payee, memo, category)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (user_id, account, fitid) DO NOTHING
+		RETURNING id, status
+	`
+	rows, err := r.db.Query(ctx, query, c.ImportID, userID, c.Account, c.FITID, c.PostedAt, c.Amount, c.Currency, c.Payee, c.Memo, c.Category)
+	if err != nil {
+		return false, translateError(err)
+	}
+	defer rows.Close()
+
+	inserted := false
+	for rows.Next() {
+		if err := rows.Scan(&c.ID, &c.Status); err != nil {
+			return false, err
+		}
+		inserted = true
+	}
+	if err := rows.Err(); err != nil {
+		return false, translateError(err)
+	}
+	return inserted, nil
+}
+
+// ListImports returns the user's newest imports with an id below beforeID
+// (0 for the first page).
+func (r *importRepository) ListImports(ctx context.Context, userID int, beforeID int, limit int) ([]*model.StatementImport, error) {
+	query := `
+			SELECT i.id, i.filename, i.format, i.created_at,
+				COUNT(c.id) FILTER (WHERE c.status = 'pending'),
+				COUNT(c.id) FILTER (WHERE c.status = 'accepted'),
+				COUNT(c.id) FILTER (WHERE c.status = 'discarded')
+			FROM statement_imports i
+			LEFT JOIN import_candidates c ON c.import_id = i.id
+			WHERE i.user_id = $1 AND ($2 = 0 OR i.id < $2)
+			GROUP BY i.id
+			ORDER BY i.id DESC
+			LIMIT $3
+	`
+	rows, err := r.db.Query(ctx, query, userID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var imports []*model.StatementImport
+	for rows.Next() {
+		var imp model.StatementImport
+		if err := rows.Scan(&imp.ID, &imp.Filename, &imp.Format, &imp.CreatedAt, &imp.Pending, &imp.Accepted, &imp.Discarded); err != nil {
+			return nil, err
+		}
+		imports = append(imports, &imp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
+// GetImport returns pgx.ErrNoRows when the user has no such import.
+func (r *importRepository) GetImport(ctx context.Context, importID, userID int) (*model.StatementImport, error) {
+	query := `
+			SELECT i.id, i.filename, i.format, i.created_at,
+				COUNT(c.id) FILTER (WHERE c.status = 'pending'),
+				COUNT(c.id) FILTER (WHERE c.status = 'accepted'),
+				COUNT(c.id) FILTER (WHERE c.status = 'discarded')
+			FROM statement_imports i
+			LEFT JOIN import_candidates c ON c.import_id = i.id
+			WHERE i.id = $1 AND i.user_id = $2
+			GROUP BY i.id
+	`
+	var imp model.StatementImport
+	err := r.db.QueryRow(ctx, query, importID, userID).Scan(&imp.ID, &imp.Filename, &imp.Format, &imp.CreatedAt, &imp.Pending, &imp.Accepted, &imp.Discarded)
+	if err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// ListCandidates returns the import's candidates in statement order,
+// optionally only those in status.
+func (r *importRepository) ListCandidates(ctx context.Context, importID int, status string) ([]*model.ImportCandidate, error) {
+	query := `
+			SELECT ` + candidateColumns + `
+			FROM import_candidates
+			WHERE import_id = $1 AND ($2 = '' OR status = $2)
+			ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, importID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []*model.ImportCandidate
+	for rows.Next() {
+		candidate, err := scanCandidate(rows)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// GetCandidateForUpdate locks the candidate for the rest of the transaction.
+// It returns pgx.ErrNoRows when the user's import has no such candidate.
+func (r *importRepository) GetCandidateForUpdate(ctx context.Context, candidateID int64, importID, userID int) (*model.ImportCandidate, error) {
+	query := `
+		SELECT ` + candidateColumns + `
+		FROM import_candidates
+		WHERE id = $1 AND import_id = $2 AND user_id = $3
+		FOR UPDATE
+	`
+	return scanCandidate(r.db.QueryRow(ctx, query, candidateID, importID, userID))
+}
+
+// UpdateCandidate saves the candidate's amount, category, status and
+// expense, stamping reviewed_at when it leaves pending.
+func (r *importRepository) UpdateCandidate(ctx context.Context, c *model.ImportCandidate) (*model.ImportCandidate, error) {
+	query := `
+		UPDATE import_candidates
+		SET amount = $2,
+			category = $3,
+			status = $4,
+			expense_id = $5,
+			reviewed_at = CASE WHEN $4 = 'pending' THEN NULL ELSE COALESCE(reviewed_at, NOW()) END
+		WHERE id = $1
+		RETURNING ` + candidateColumns + `
+	`
+	updated, err := scanCandidate(r.db.QueryRow(ctx, query, c.ID, c.Amount, c.Category, c.Status, c.ExpenseID))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return updated, nil
+}
+
+const candidateColumns = `id, import_id, account, fitid, posted_at, amount, currency, payee, memo,
+			category, status, expense_id, reviewed_at`
+
+func scanCandidate(row pgx.Row) (*model.ImportCandidate, error) {
+	var c model.ImportCandidate
+	err := row.Scan(&c.ID, &c.ImportID, &c.Account, &c.FITID, &c.PostedAt, &c.Amount, &c.Currency, &c.Payee, &c.Memo,
+		&c.Category, &c.Status, &c.ExpenseID, &c.ReviewedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expense-tracker/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type OutboxRepository interface {
+	CreateEvent(ctx context.Context, event *model.OutboxEvent) error
+	DispatchEvents(ctx context.Context, limit int) (int64, error)
+	DeleteExpiredEvents(ctx context.Context, before time.Time, undispatched bool) (int64, error)
+	ListUserEvents(ctx context.Context, userID int, afterSeq int64, types []string, limit int) ([]*model.OutboxEvent, error)
+	LatestUserEventSeq(ctx context.Context, userID int) (int64, error)
+	PurgedThrough(ctx context.Context, userID int) (int64, error)
+}
+
+type outboxRepository struct {
+	db DBTX
+}
+
+func NewOutboxRepository(db DBTX) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// CreateEvent appends an event; bind the repository to the transaction of
+// the change the event describes.
+func (r *outboxRepository) CreateEvent(ctx context.Context, event *model.OutboxEvent) error {
+	query := `
+		INSERT INTO outbox_events (user_id, event_type, payload)
+		VALUES ($1, $2, $3)
+		RETURNING id, seq, created_at
+	`
+	return r.db.QueryRow(ctx, query, event.UserID, event.Type, event.Payload).Scan(&event.ID, &event.Seq, &event.CreatedAt)
+}
+
+// DispatchEvents fans up to limit undispatched events, oldest first, out to
+// a pending delivery per subscribed endpoint and marks them dispatched, all
+// in one statement. Events locked by another dispatcher are skipped.
+func (r *outboxRepository) DispatchEvents(ctx context.Context, limit int) (int64, error) {
+	query := `
+		WITH batch AS (
+			SELECT id, user_id, event_type
+			FROM outbox_events
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), fanout AS (
+			INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type)
+			SELECT w.id, b.id, b.event_type
+			FROM batch b
+			JOIN webhook_endpoints w ON w.user_id = b.user_id AND b.event_type = ANY(w.events)
+			ON CONFLICT (endpoint_id, event_id) DO NOTHING
+		)
+		UPDATE outbox_events o
+		SET dispatched_at = NOW()
+		FROM batch
+		WHERE o.id = batch.id
+	`
+	tag, err := r.db.Exec(ctx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DeleteExpiredEvents removes events dispatched before the cutoff along
+// with their deliveries, and raises each user's purge mark past them. Events
+// with a delivery still pending are kept. With undispatched, events created
+// before the cutoff go too even if no dispatcher ever picked them up, which
+// is how the outbox stays bounded while webhooks are off.
+func (r *outboxRepository) DeleteExpiredEvents(ctx context.Context, before time.Time, undispatched bool) (int64, error) {
+	query := `
+		WITH deleted AS (
+			DELETE FROM outbox_events e
+			WHERE (e.dispatched_at < $1 OR ($2 AND e.dispatched_at IS NULL AND e.created_at < $1))
+			  AND NOT EXISTS (
+			      SELECT 1 FROM webhook_deliveries d
+			      WHERE d.event_id = e.id AND d.status = 'pending'
+			  )
+			RETURNING e.user_id, e.seq
+		), marks AS (
+			INSERT INTO outbox_purges (user_id, through_seq)
+			SELECT user_id, MAX(seq) FROM deleted GROUP BY user_id
+			ON CONFLICT (user_id) DO UPDATE
+			SET through_seq = GREATEST(outbox_purges.through_seq, EXCLUDED.through_seq)
+		)
+		SELECT COUNT(*) FROM deleted
+	`
+	var purged int64
+	err := r.db.QueryRow(ctx, query, before, undispatched).Scan(&purged)
+	return purged, err
+}
+
+// ListUserEvents returns up to limit of the user's events of the given types
+// with a seq above afterSeq, in seq order.
+func (r *outboxRepository) ListUserEvents(ctx context.Context, userID int, afterSeq int64, types []string, limit int) ([]*model.OutboxEvent, error) {
+	query := `
+		SELECT id, seq, user_id, event_type, payload, created_at
+		FROM outbox_events
+		WHERE user_id = $1 AND seq > $2 AND event_type = ANY($3)
+		ORDER BY seq
+		LIMIT $4
+	`
+	rows, err := r.db.Query(ctx, query, userID, afterSeq, types, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*model.OutboxEvent
+	for rows.Next() {
+		event := &model.OutboxEvent{}
+		if err := rows.Scan(&event.ID, &event.Seq, &event.UserID, &event.Type, &event.Payload, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// LatestUserEventSeq returns the seq of the user's newest event, or 0.
+func (r *outboxRepository) LatestUserEventSeq(ctx context.Context, userID int) (int64, error) {
+	var seq int64
+	err := r.db.QueryRow(ctx, `SELECT COALESCE(MAX(seq), 0) FROM outbox_events WHERE user_id = $1`, userID).Scan(&seq)
+	return seq, err
+}
+
+// PurgedThrough returns the highest seq of the user's events that have been
+// purged, or 0 if none have.
+func (r *outboxRepository) PurgedThrough(ctx context.Context, userID int) (int64, error) {
+	var seq int64
+	err := r.db.QueryRow(ctx, `SELECT through_seq FROM outbox_purges WHERE user_id = $1`, userID).Scan(&seq)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return seq, err
+}
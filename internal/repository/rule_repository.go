@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"expense-tracker/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type RuleRepository interface {
+	ListRules(ctx context.Context, userID int) ([]*model.Rule, error)
+	GetRule(ctx context.Context, ruleID, userID int) (*model.Rule, error)
+	CountRules(ctx context.Context, userID int) (int, error)
+	CreateRule(ctx context.Context, userID int, rule *model.Rule) (*model.Rule, error)
+	UpdateRule(ctx context.Context, userID int, rule *model.Rule) (*model.Rule, error)
+	DeleteRule(ctx context.Context, ruleID, userID int) error
+}
+
+type ruleRepository struct {
+	db DBTX
+}
+
+func NewRuleRepository(db DBTX) RuleRepository {
+	return &ruleRepository{db: db}
+}
+
+const ruleColumns = `id, name, priority, enabled, description_contains, description_regex, min_amount, max_amount,
+			weekdays, set_category, add_tags, mark_reimbursable, created_at, updated_at`
+
+// ListRules returns the user's rules in the order they run.
+func (r *ruleRepository) ListRules(ctx context.Context, userID int) ([]*model.Rule, error) {
+	query := `
+			SELECT ` + ruleColumns + `
+			FROM categorization_rules
+			WHERE user_id = $1
+			ORDER BY priority, id
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*model.Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetRule returns pgx.ErrNoRows when the user has no such rule.
+func (r *ruleRepository) GetRule(ctx context.Context, ruleID, userID int) (*model.Rule, error) {
+	query := `
+			SELECT ` + ruleColumns + `
+			FROM categorization_rules
+			WHERE id = $1 AND user_id = $2
+	`
+	return scanRule(r.db.QueryRow(ctx, query, ruleID, userID))
+}
+
+func (r *ruleRepository) CountRules(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM categorization_rules WHERE user_id = $1`, userID).Scan(&count)
+	return count, err
+}
+
+func (r *ruleRepository) CreateRule(ctx context.Context, userID int, rule *model.Rule) (*model.Rule, error) {
+	query := `
+		INSERT INTO categorization_rules (user_id, name, priority, enabled, description_contains, description_regex,
+			min_amount, max_amount, weekdays, set_category, add_tags, mark_reimbursable)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING ` + ruleColumns + `
+	`
+	created, err := scanRule(r.db.QueryRow(ctx, query, userID, rule.Name, rule.Priority, rule.Enabled,
+		rule.DescriptionContains, rule.DescriptionRegex, rule.MinAmount, rule.MaxAmount,
+		rule.Weekdays, rule.SetCategory, rule.AddTags, rule.MarkReimbursable))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return created, nil
+}
+
+// UpdateRule replaces every setting of the rule with rule.ID. It returns
+// pgx.ErrNoRows when the user has no such rule.
+func (r *ruleRepository) UpdateRule(ctx context.Context, userID int, rule *model.Rule) (*model.Rule, error) {
+	query := `
+		UPDATE categorization_rules
+		SET name = $3, priority = $4, enabled = $5, description_contains = $6, description_regex = $7,
+			min_amount = $8, max_amount = $9, weekdays = $10, set_category = $11, add_tags = $12,
+			mark_reimbursable = $13, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING ` + ruleColumns + `
+	`
+	updated, err := scanRule(r.db.QueryRow(ctx, query, rule.ID, userID, rule.Name, rule.Priority, rule.Enabled,
+		rule.DescriptionContains, rule.DescriptionRegex, rule.MinAmount, rule.MaxAmount,
+		rule.Weekdays, rule.SetCategory, rule.AddTags, rule.MarkReimbursable))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return updated, nil
+}
+
+// DeleteRule returns pgx.ErrNoRows when the user has no such rule.
+func (r *ruleRepository) DeleteRule(ctx context.Context, ruleID, userID int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM categorization_rules WHERE id = $1 AND user_id = $2`, ruleID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func scanRule(row pgx.Row) (*model.Rule, error) {
+	var rule model.Rule
+	err := row.Scan(&rule.ID, &rule.Name, &rule.Priority, &rule.Enabled, &rule.DescriptionContains, &rule.DescriptionRegex,
+		&rule.MinAmount, &rule.MaxAmount, &rule.Weekdays, &rule.SetCategory, &rule.AddTags, &rule.MarkReimbursable,
+		&rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"expense-tracker/internal/model"
+	"time"
+)
+
+// SyncRepository serves offline sync: expenses addressed by their uuid, and
+// the change log kept in expenses.change_seq and expense_tombstones.
+type SyncRepository interface {
+	CreateExpenseWithUUID(ctx context.Context, userID int, uuid string, e *model.Expense) (*model.Expense, error)
+	GetExpenseByUUIDForUpdate(ctx context.Context, userID int, uuid string) (*model.SyncExpense, error)
+	ListChanges(ctx context.Context, userID int, afterSeq int64, limit int) ([]*model.SyncExpense, error)
+}
+
+type syncRepository struct {
+	db DBTX
+}
+
+func NewSyncRepository(db DBTX) SyncRepository {
+	return &syncRepository{db: db}
+}
+
+// CreateExpenseWithUUID is CreateDetailedExpense under a client-chosen uuid.
+// A zero CreatedAt means now.
+func (r *syncRepository) CreateExpenseWithUUID(ctx context.Context, userID int, uuid string, e *model.Expense) (*model.Expense, error) {
+	query := `
+		INSERT INTO expenses (user_id, uuid, amount, category, description, tags, reimbursable, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8, NOW()))
+		RETURNING id, user_id, amount, category, created_at, version, description, tags, reimbursable
+	`
+	var createdAt *time.Time
+	if !e.CreatedAt.IsZero() {
+		createdAt = &e.CreatedAt
+	}
+	var expense model.Expense
+	err := r.db.QueryRow(ctx, query, userID, uuid, e.Amount, e.Category, e.Description, nonNilTags(e.Tags), e.Reimbursable, createdAt).Scan(
+		&expense.ID,
+		&expense.UserID,
+		&expense.Amount,
+		&expense.Category,
+		&expense.CreatedAt,
+		&expense.Version,
+		&expense.Description,
+		&expense.Tags,
+		&expense.Reimbursable,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &expense, nil
+}
+
+// GetExpenseByUUIDForUpdate locks the user's expense with the given uuid,
+// trashed or not, for the rest of the transaction. It returns pgx.ErrNoRows
+// when there is none.
+func (r *syncRepository) GetExpenseByUUIDForUpdate(ctx context.Context, userID int, uuid string) (*model.SyncExpense, error) {
+	query := `
+		SELECT uuid::text, id, amount, category, description, tags, reimbursable, created_at, updated_at, version, deleted_at IS NOT NULL, change_seq
+		FROM expenses
+		WHERE user_id = $1 AND uuid = $2
+		FOR UPDATE
+	`
+	var expense model.SyncExpense
+	err := r.db.QueryRow(ctx, query, userID, uuid).Scan(
+		&expense.UUID,
+		&expense.ID,
+		&expense.Amount,
+		&expense.Category,
+		&expense.Description,
+		&expense.Tags,
+		&expense.Reimbursable,
+		&expense.CreatedAt,
+		&expense.UpdatedAt,
+		&expense.Version,
+		&expense.Deleted,
+		&expense.ChangeSeq,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// ListChanges returns up to limit of the user's changes after afterSeq in
+// sequence order: the current state of every expense changed since, and a
+// tombstone for every expense purged since. With afterSeq 0 the client has
+// nothing yet, so deleted expenses are left out.
+func (r *syncRepository) ListChanges(ctx context.Context, userID int, afterSeq int64, limit int) ([]*model.SyncExpense, error) {
+	query := `
+		SELECT uuid::text, id, amount, category, description, tags, reimbursable, created_at, updated_at, version, deleted_at IS NOT NULL, change_seq
+		FROM expenses
+		WHERE user_id = $1 AND change_seq > $2 AND ($2 > 0 OR deleted_at IS NULL)
+		UNION ALL
+		SELECT uuid::text, expense_id, NULL, NULL, NULL, NULL, NULL, NULL, deleted_at, NULL, TRUE, change_seq
+		FROM expense_tombstones
+		WHERE user_id = $1 AND change_seq > $2 AND $2 > 0
+		ORDER BY change_seq
+		LIMIT $3
+	`
+	rows, err := r.db.Query(ctx, query, userID, afterSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*model.SyncExpense
+	for rows.Next() {
+		var (
+			change       model.SyncExpense
+			amount       *float64
+			category     *string
+			description  *string
+			reimbursable *bool
+			version      *int
+		)
+		if err := rows.Scan(
+			&change.UUID,
+			&change.ID,
+			&amount,
+			&category,
+			&description,
+			&change.Tags,
+			&reimbursable,
+			&change.CreatedAt,
+			&change.UpdatedAt,
+			&version,
+			&change.Deleted,
+			&change.ChangeSeq,
+		); err != nil {
+			return nil, err
+		}
+		if amount != nil {
+			change.Amount = *amount
+		}
+		if category != nil {
+			change.Category = *category
+		}
+		if description != nil {
+			change.Description = *description
+		}
+		if reimbursable != nil {
+			change.Reimbursable = *reimbursable
+		}
+		if version != nil {
+			change.Version = *version
+		}
+		changes = append(changes, &change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
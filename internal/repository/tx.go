@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expense-tracker/internal/logging"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Repositories groups every repository bound to the same transaction.
+type Repositories struct {
+	Expenses ExpenseRepository
+	Users    UserRepository
+	Audit    AuditRepository
+	Budgets  BudgetRepository
+	Outbox   OutboxRepository
+	Sync     SyncRepository
+	Imports  ImportRepository
+	Rules    RuleRepository
+	Webhooks WebhookRepository
+}
+
+type TxOptions struct {
+	IsoLevel pgx.TxIsoLevel
+	ReadOnly bool
+	// MaxRetries is how many times a transaction that failed with a
+	// serialization failure or deadlock is re-run from the start.
+	MaxRetries int
+}
+
+// TxManager runs units of work that span several repository calls in one pgx.Tx.
+type TxManager struct {
+	pool     TxBeginner
+	defaults TxOptions
+}
+
+func NewTxManager(pool TxBeginner, defaults TxOptions) *TxManager {
+	return &TxManager{pool: pool, defaults: defaults}
+}
+
+// WithinTx runs fn in a transaction using the manager's default options.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(repos Repositories) error) error {
+	return m.WithinTxOptions(ctx, m.defaults, fn)
+}
+
+// WithinTxOptions runs fn in a transaction that is committed if fn returns nil
+// and rolled back otherwise. Because fn may be retried it must not have side
+// effects outside the transaction.
+func (m *TxManager) WithinTxOptions(ctx context.Context, opts TxOptions, fn func(repos Repositories) error) error {
+	txOpts := pgx.TxOptions{IsoLevel: opts.IsoLevel}
+	if opts.ReadOnly {
+		txOpts.AccessMode = pgx.ReadOnly
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := pgx.BeginTxFunc(ctx, m.pool, txOpts, func(tx pgx.Tx) error {
+			return fn(Repositories{
+				Expenses: &expenseRepository{db: tx, reader: tx},
+				Users:    &userRepository{db: tx},
+				Audit:    &auditRepository{db: tx, reader: tx},
+				Budgets:  &budgetRepository{db: tx},
+				Outbox:   &outboxRepository{db: tx},
+				Sync:     &syncRepository{db: tx},
+				Imports:  &importRepository{db: tx},
+				Rules:    &ruleRepository{db: tx},
+				Webhooks: &webhookRepository{db: tx},
+			})
+		})
+		if err == nil || !isRetryable(err) || attempt >= opts.MaxRetries {
+			return err
+		}
+
+		backoff := retryBackoff(attempt)
+		logging.FromContext(ctx).Warn("transaction conflict, retrying", "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("transaction retry aborted: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// isRetryable reports whether err is a serialization_failure or deadlock_detected.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
+
+// retryBackoff doubles from 10ms per attempt, with up to 50% jitter.
+func retryBackoff(attempt int) time.Duration {
+	base := 10 * time.Millisecond << min(attempt, 6)
+	return base + rand.N(base/2)
+}
+
+// ParseIsoLevel maps a config value such as "repeatable read" to a pgx isolation level.
+func ParseIsoLevel(s string) (pgx.TxIsoLevel, error) {
+	switch level := pgx.TxIsoLevel(s); level {
+	case "":
+		return pgx.ReadCommitted, nil
+	case pgx.Serializable, pgx.RepeatableRead, pgx.ReadCommitted, pgx.ReadUncommitted:
+		return level, nil
+	}
+	return "", fmt.Errorf("unknown isolation level %q", s)
+}
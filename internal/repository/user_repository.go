@@ -3,22 +3,21 @@ package repository
 import (
 	"context"
 	"expense-tracker/internal/model"
-
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, email, passwordHash string) (*model.User, error)
 	LogInUser(ctx context.Context, email string) (*model.User, error)
 	GetUser(ctx context.Context, id int) (*model.User, error)
+	LockUser(ctx context.Context, id int) error
 }
 
 type userRepository struct {
-	pool *pgxpool.Pool
+	db DBTX
 }
 
-func NewUserRepository(pool *pgxpool.Pool) UserRepository {
-	return &userRepository{pool: pool}
+func NewUserRepository(db DBTX) UserRepository {
+	return &userRepository{db: db}
 }
 
 func (r *userRepository) CreateUser(ctx context.Context, email, passwordHash string) (*model.User, error) {
@@ -29,14 +28,14 @@ func (r *userRepository) CreateUser(ctx context.Context, email, passwordHash str
 `
 	var user model.User
 
-	err := r.pool.QueryRow(ctx, query, email, passwordHash).Scan(
+	err := r.db.QueryRow(ctx, query, email, passwordHash).Scan(
 		&user.ID,
 		&user.Email,
 		&user.CreatedAt,
 	)
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 	return &user, nil
 }
@@ -49,7 +48,7 @@ func (r *userRepository) LogInUser(ctx context.Context, email string) (*model.Us
 	`
 	var user model.User
 
-	err := r.pool.QueryRow(ctx, query, email).Scan(
+	err := r.db.QueryRow(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
@@ -69,7 +68,7 @@ func (r *userRepository) GetUser(ctx context.Context, id int) (*model.User, erro
 	`
 	var user model.User
 
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	err := r.db.QueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.CreatedAt,
@@ -80,3 +79,11 @@ func (r *userRepository) GetUser(ctx context.Context, id int) (*model.User, erro
 	}
 	return &user, nil
 }
+
+// LockUser takes a row lock on the user for the rest of the transaction, so
+// that per-user checks such as count limits run one at a time. It returns
+// pgx.ErrNoRows when there is no such user.
+func (r *userRepository) LockUser(ctx context.Context, id int) error {
+	var locked int
+	return r.db.QueryRow(ctx, `SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE`, id).Scan(&locked)
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"expense-tracker/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, userID int, url, secret string, events []string) (*model.WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, userID int) ([]*model.WebhookEndpoint, error)
+	GetEndpoint(ctx context.Context, endpointID, userID int) (*model.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, endpointID, userID int) error
+	ListDeliveries(ctx context.Context, endpointID int, status string, beforeID int64, limit int) ([]*model.WebhookDelivery, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*model.PendingDelivery, error)
+	MarkDelivered(ctx context.Context, deliveryID int64, statusCode int) error
+	MarkFailed(ctx context.Context, deliveryID int64, statusCode *int, lastError string, nextAttemptAt *time.Time) error
+	RetryDelivery(ctx context.Context, deliveryID int64, endpointID int) (*model.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db DBTX
+}
+
+func NewWebhookRepository(db DBTX) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateEndpoint(ctx context.Context, userID int, url, secret string, events []string) (*model.WebhookEndpoint, error) {
+	query := `
+		INSERT INTO webhook_endpoints (user_id, url, secret, events)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, url, secret, events, created_at
+	`
+	var endpoint model.WebhookEndpoint
+	err := r.db.QueryRow(ctx, query, userID, url, secret, events).Scan(
+		&endpoint.ID,
+		&endpoint.URL,
+		&endpoint.Secret,
+		&endpoint.Events,
+		&endpoint.CreatedAt,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &endpoint, nil
+}
+
+func (r *webhookRepository) ListEndpoints(ctx context.Context, userID int) ([]*model.WebhookEndpoint, error) {
+	query := `
+			SELECT id, url, secret, events, created_at
+			FROM webhook_endpoints
+			WHERE user_id = $1
+			ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []*model.WebhookEndpoint
+	for rows.Next() {
+		var endpoint model.WebhookEndpoint
+		if err := rows.Scan(&endpoint.ID, &endpoint.URL, &endpoint.Secret, &endpoint.Events, &endpoint.CreatedAt); err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, &endpoint)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// GetEndpoint returns pgx.ErrNoRows when the user has no such endpoint.
+func (r *webhookRepository) GetEndpoint(ctx context.Context, endpointID, userID int) (*model.WebhookEndpoint, error) {
+	query := `
+			SELECT id, url, secret, events, created_at
+			FROM webhook_endpoints
+			WHERE id = $1 AND user_id = $2
+	`
+	var endpoint model.WebhookEndpoint
+	err := r.db.QueryRow(ctx, query, endpointID, userID).Scan(&endpoint.ID, &endpoint.URL, &endpoint.Secret, &endpoint.Events, &endpoint.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// DeleteEndpoint removes the endpoint and its delivery log. It returns
+// pgx.ErrNoRows when the user has no such endpoint.
+func (r *webhookRepository) DeleteEndpoint(ctx context.Context, endpointID, userID int) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM webhook_endpoints WHERE id = $1 AND user_id = $2`, endpointID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ListDeliveries returns the endpoint's newest deliveries with an id below
+// beforeID (0 for the first page), optionally only those in status.
+func (r *webhookRepository) ListDeliveries(ctx context.Context, endpointID int, status string, beforeID int64, limit int) ([]*model.WebhookDelivery, error) {
+	query := `
+			SELECT id, endpoint_id, event_id, event_type, status, attempts,
+				CASE WHEN status = 'pending' THEN next_attempt_at END,
+				last_status_code, COALESCE(last_error, ''), created_at, delivered_at
+			FROM webhook_deliveries
+			WHERE endpoint_id = $1 AND ($2 = '' OR status = $2) AND ($3 = 0 OR id < $3)
+			ORDER BY id DESC
+			LIMIT $4
+	`
+	rows, err := r.db.Query(ctx, query, endpointID, status, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*model.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDeliveries takes up to limit due deliveries, counts the attempt and
+// hides them from other workers for lease. A worker that dies mid-send
+// leaves the delivery to be retried once the lease runs out.
+func (r *webhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*model.PendingDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET attempts = d.attempts + 1,
+			next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM webhook_endpoints w, outbox_events e
+		WHERE d.id IN (
+				SELECT id
+				FROM webhook_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= NOW()
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			AND w.id = d.endpoint_id
+			AND e.id = d.event_id
+		RETURNING d.id, d.attempts, w.url, w.secret, e.id, e.user_id, e.event_type, e.payload, e.created_at
+	`
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []*model.PendingDelivery
+	for rows.Next() {
+		var d model.PendingDelivery
+		if err := rows.Scan(
+			&d.ID,
+			&d.Attempts,
+			&d.URL,
+			&d.Secret,
+			&d.Event.ID,
+			&d.Event.UserID,
+			&d.Event.Type,
+			&d.Event.Payload,
+			&d.Event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func (r *webhookRepository) MarkDelivered(ctx context.Context, deliveryID int64, statusCode int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', last_status_code = $2, last_error = NULL, delivered_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, deliveryID, statusCode)
+	return err
+}
+
+// MarkFailed records a failed attempt. A nil nextAttemptAt moves the
+// delivery to the dead-letter state.
+func (r *webhookRepository) MarkFailed(ctx context.Context, deliveryID int64, statusCode *int, lastError string, nextAttemptAt *time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = CASE WHEN $4::timestamptz IS NULL THEN 'dead' ELSE 'pending' END,
+			last_status_code = $2,
+			last_error = $3,
+			next_attempt_at = COALESCE($4, next_attempt_at)
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, deliveryID, statusCode, lastError, nextAttemptAt)
+	return err
+}
+
+// RetryDelivery makes a dead delivery due now with a fresh set of attempts.
+// It returns pgx.ErrNoRows unless the endpoint has such a dead delivery.
+func (r *webhookRepository) RetryDelivery(ctx context.Context, deliveryID int64, endpointID int) (*model.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+		WHERE id = $1 AND endpoint_id = $2 AND status = 'dead'
+		RETURNING id, endpoint_id, event_id, event_type, status, attempts, next_attempt_at,
+			last_status_code, COALESCE(last_error, ''), created_at, delivered_at
+	`
+	return scanDelivery(r.db.QueryRow(ctx, query, deliveryID, endpointID))
+}
+
+func scanDelivery(row pgx.Row) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	if err := row.Scan(
+		&d.ID,
+		&d.EndpointID,
+		&d.EventID,
+		&d.EventType,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastStatusCode,
+		&d.LastError,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,23 @@
+// Package reqctx carries per-request metadata from the HTTP layer down to
+// services without threading extra parameters through every call.
+package reqctx
+
+import "context"
+
+type Meta struct {
+	RequestID string
+	IP        string
+}
+
+type metaKey struct{}
+
+func WithMeta(ctx context.Context, meta Meta) context.Context {
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+// MetaFrom returns the request metadata stored in ctx, or the zero Meta for
+// work that did not originate from a request (e.g. background jobs).
+func MetaFrom(ctx context.Context) Meta {
+	meta, _ := ctx.Value(metaKey{}).(Meta)
+	return meta
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"expense-tracker/internal/middleware"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// legacyDeprecatedAt is when /api/v1 superseded the unversioned routes.
+var legacyDeprecatedAt = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// registerLegacy keeps the routes from before /api/v1 working as aliases of
+// their v1 successors, with Deprecation and Sunset headers on every
+// response. OpenAPI validation only knows the v1 paths and lets these
+// through; the handlers still validate their input. It returns the routes it
+// added as "METHOD /path".
+func registerLegacy(router *gin.Engine, deps Deps, m chains, sunset time.Time) map[string]bool {
+	added := map[string]bool{}
+	alias := func(chain []gin.HandlerFunc, method, path, successor string, handlers ...gin.HandlerFunc) {
+		deprecated := middleware.Deprecated(legacyDeprecatedAt, sunset, V1Prefix+successor)
+		router.Handle(method, path, slices.Concat([]gin.HandlerFunc{deprecated}, chain, handlers)...)
+		added[method+" "+path] = true
+	}
+
+	alias(m.public, http.MethodPost, "/users/register", "/auth/register", m.idempotency, deps.User.CreateUserHandler)
+	alias(m.public, http.MethodPost, "/users/login", "/auth/login", deps.User.LogInUserHandler)
+
+	alias(m.user, http.MethodGet, "/users/me", "/users/me", deps.User.GetUserHandler)
+	alias(m.user, http.MethodGet, "/users/me/activity", "/users/me/activity", deps.Audit.GetActivityHandler)
+	alias(m.user, http.MethodPost, "/users/expenses", "/expenses", deps.Expense.AddExpenseHandler)
+	alias(m.user, http.MethodGet, "/users/expenses", "/expenses", deps.Expense.GetAllExpenseHandler)
+	alias(m.user, http.MethodGet, "/users/expenses/trash", "/expenses/trash", deps.Expense.GetTrashHandler)
+	alias(m.user, http.MethodPost, "/users/expenses/batch", "/expenses/batch", deps.Expense.BatchExpenseHandler)
+	alias(m.user, http.MethodGet, "/expenses/:id", "/expenses/:id", deps.Expense.GetExpenseByIDHandler)
+	alias(m.user, http.MethodPut, "/expenses/:id", "/expenses/:id", deps.Expense.UpdateExpenseHandler)
+	alias(m.user, http.MethodPatch, "/expenses/:id", "/expenses/:id", deps.Expense.PatchExpenseHandler)
+	alias(m.user, http.MethodDelete, "/expenses/:id", "/expenses/:id", deps.Expense.DeleteExpenseHandler)
+	alias(m.user, http.MethodPost, "/expenses/:id/restore", "/expenses/:id/restore", deps.Expense.RestoreExpenseHandler)
+	alias(m.user, http.MethodGet, "/expenses/:id/history", "/expenses/:id/history", deps.Audit.GetExpenseHistoryHandler)
+	return added
+}
@@ -0,0 +1,193 @@
+// Package router builds the HTTP API: global middleware, health probes, one
+// route group per API version and the deprecated unversioned aliases.
+package router
+
+import (
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/handler"
+	"expense-tracker/internal/middleware"
+	"expense-tracker/internal/openapi"
+	"expense-tracker/internal/ratelimit"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/utils"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Deps are what the routes dispatch to.
+type Deps struct {
+	User    *handler.UserHandler
+	Expense *handler.ExpenseHandler
+	Audit   *handler.AuditHandler
+	Webhook *handler.WebhookHandler
+	Budget  *handler.BudgetHandler
+	Sync    *handler.SyncHandler
+	Import  *handler.ImportHandler
+	Rule    *handler.RuleHandler
+	Health  *handler.HealthHandler
+	// GraphQL is nil when the feature is off.
+	GraphQL *handler.GraphQLHandler
+	// Stream is nil when streaming is off.
+	Stream *handler.StreamHandler
+
+	Tokens      *utils.TokenManager
+	Idempotency repository.IdempotencyRepository
+	// Limiter backs rate limiting; it is unused when the feature is off.
+	Limiter ratelimit.Limiter
+}
+
+// chains are the per-route middleware every API version shares.
+type chains struct {
+	public      []gin.HandlerFunc
+	user        []gin.HandlerFunc
+	idempotency gin.HandlerFunc
+}
+
+// New builds the router. It fails on invalid settings and when the routes
+// and the OpenAPI document disagree.
+func New(cfg *config.Config, deps Deps) (*gin.Engine, error) {
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid server.trusted_proxies: %w", err)
+	}
+	routeTimeouts, err := cfg.Server.RouteTimeoutOverrides()
+	if err != nil {
+		return nil, err
+	}
+	bodyLimits := map[string]int64{
+		"POST " + V1Prefix + "/imports": int64(cfg.Import.MaxFileBytes) + handler.MultipartOverhead,
+	}
+	if deps.Stream != nil {
+		// streams stay open as long as the client listens
+		routeTimeouts["GET "+V1Prefix+"/expenses/stream"] = 0
+		routeTimeouts["GET "+V1Prefix+"/expenses/stream/ws"] = 0
+	}
+	router.Use(
+		middleware.Recovery(),
+		otelgin.Middleware(cfg.Tracing.ServiceName),
+		middleware.RequestContext(),
+		middleware.Metrics(),
+		middleware.Errors(),
+		middleware.CORS(cfg.CORS),
+		middleware.Timeout(cfg.Server.RequestTimeout, routeTimeouts),
+	)
+
+	router.NoRoute(middleware.NotFound)
+
+	// Health checks
+	router.GET("/livez", deps.Health.LivezHandler)
+	router.GET("/readyz", deps.Health.ReadyzHandler)
+	router.GET("/health", deps.Health.LivezHandler) // kept for existing monitors
+
+	apiDoc, err := openapi.Load()
+	if err != nil {
+		return nil, err
+	}
+	m, err := newChains(cfg, deps, apiDoc, bodyLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	if deps.GraphQL == nil {
+		apiDoc.Paths.Delete(V1Prefix + "/graphql")
+	}
+	if deps.Stream == nil {
+		apiDoc.Paths.Delete(V1Prefix + "/expenses/stream")
+		apiDoc.Paths.Delete(V1Prefix + "/expenses/stream/ws")
+	}
+
+	v1 := router.Group(V1Prefix)
+	if err := openapi.Register(v1, apiDoc); err != nil {
+		return nil, err
+	}
+	registerV1(v1, deps, m)
+
+	legacy := map[string]bool{}
+	if cfg.Features.LegacyRoutes {
+		sunset, err := cfg.Server.LegacySunsetDate()
+		if err != nil {
+			return nil, err
+		}
+		legacy = registerLegacy(router, deps, m, sunset)
+	}
+
+	// Every route must be documented, and every documented route served
+	var documented gin.RoutesInfo
+	for _, route := range router.Routes() {
+		if !legacy[route.Method+" "+route.Path] {
+			documented = append(documented, route)
+		}
+	}
+	if problems := openapi.Mismatches(apiDoc, documented); len(problems) > 0 {
+		return nil, fmt.Errorf("routes and openapi document disagree: %s", strings.Join(problems, "; "))
+	}
+
+	// An override that matches no route would be ignored silently, so flag it
+	knownRoutes := map[string]bool{}
+	for _, route := range router.Routes() {
+		knownRoutes[route.Method+" "+route.Path] = true
+	}
+	for route := range routeTimeouts {
+		if !knownRoutes[route] {
+			slog.Warn("server.route_timeouts entry matches no route", "route", route)
+		}
+	}
+	return router, nil
+}
+
+// newChains assembles the optional middleware; a disabled feature becomes a
+// pass-through. Bodies are capped ahead of OpenAPI validation, which reads
+// them whole.
+func newChains(cfg *config.Config, deps Deps, apiDoc *openapi3.T, bodyLimits map[string]int64) (chains, error) {
+	passThrough := func(c *gin.Context) { c.Next() }
+
+	idempotency := gin.HandlerFunc(passThrough)
+	if cfg.Features.Idempotency {
+		idempotency = middleware.Idempotency(deps.Idempotency, cfg.Idempotency.TTL)
+	}
+
+	// Rate limiting
+	authRateLimit, apiRateLimit := gin.HandlerFunc(passThrough), gin.HandlerFunc(passThrough)
+	if cfg.Features.RateLimiting {
+		authPolicy, err := ratelimit.ParsePolicy("auth", cfg.RateLimit.Auth)
+		if err != nil {
+			return chains{}, fmt.Errorf("invalid rate_limit.auth: %w", err)
+		}
+		apiPolicy, err := ratelimit.ParsePolicy("api", cfg.RateLimit.API)
+		if err != nil {
+			return chains{}, fmt.Errorf("invalid rate_limit.api: %w", err)
+		}
+		authRateLimit = middleware.RateLimit(deps.Limiter, authPolicy)
+		apiRateLimit = middleware.RateLimit(deps.Limiter, apiPolicy)
+	}
+
+	// OpenAPI validation
+	openAPIValidation := gin.HandlerFunc(passThrough)
+	if cfg.OpenAPI.ValidateRequests || cfg.OpenAPI.ValidateResponses {
+		var err error
+		openAPIValidation, err = middleware.OpenAPI(apiDoc, cfg.OpenAPI)
+		if err != nil {
+			return chains{}, err
+		}
+	}
+
+	bodyLimit := middleware.BodyLimit(int64(cfg.Server.MaxBodyBytes), bodyLimits)
+
+	return chains{
+		public: []gin.HandlerFunc{authRateLimit, bodyLimit, openAPIValidation},
+		user: []gin.HandlerFunc{
+			middleware.AuthMiddleware(deps.Tokens),
+			apiRateLimit,
+			bodyLimit,
+			openAPIValidation,
+			idempotency,
+			middleware.ReadYourWrites(cfg.Database.ReadYourWritesWindow),
+		},
+		idempotency: idempotency,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"expense-tracker/internal/config"
+	"expense-tracker/internal/handler"
+	"expense-tracker/internal/openapi"
+	"expense-tracker/internal/utils"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testConfig(t *testing.T, args ...string) *config.Config {
+	t.Helper()
+	args = append([]string{
+		"-jwt.secret=abcdefghijklmnopqrstuvwxyz0123456789",
+		"-database.url=postgres://test@localhost/test",
+		"-features.rate_limiting=false",
+		"-features.idempotency=false",
+		"-features.legacy_routes=false",
+	}, args...)
+	cfg, _, err := config.Load(args)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	return cfg
+}
+
+// stubDeps are handlers that are never called; building the router only
+// needs their methods.
+func stubDeps(cfg *config.Config, optional bool) Deps {
+	deps := Deps{
+		User:    &handler.UserHandler{},
+		Expense: &handler.ExpenseHandler{},
+		Audit:   &handler.AuditHandler{},
+		Webhook: &handler.WebhookHandler{},
+		Budget:  &handler.BudgetHandler{},
+		Sync:    &handler.SyncHandler{},
+		Import:  &handler.ImportHandler{},
+		Rule:    &handler.RuleHandler{},
+		Health:  &handler.HealthHandler{},
+		Tokens:  utils.NewTokenManager(cfg.JWT),
+	}
+	if optional {
+		deps.GraphQL = &handler.GraphQLHandler{}
+		deps.Stream = &handler.StreamHandler{}
+	}
+	return deps
+}
+
+func TestRoutesMatchDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		optional bool
+	}{
+		{name: "all features", optional: true},
+		{name: "optional features off", optional: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			cfg := testConfig(t)
+			engine, err := New(cfg, stubDeps(cfg, tt.optional))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			doc, err := openapi.Load()
+			if err != nil {
+				t.Fatalf("load document: %v", err)
+			}
+			if !tt.optional {
+				doc.Paths.Delete(V1Prefix + "/graphql")
+				doc.Paths.Delete(V1Prefix + "/expenses/stream")
+				doc.Paths.Delete(V1Prefix + "/expenses/stream/ws")
+			}
+			if problems := openapi.Mismatches(doc, engine.Routes()); len(problems) > 0 {
+				t.Errorf("routes and document disagree:\n%s", strings.Join(problems, "\n"))
+			}
+		})
+	}
+}
+
+func TestUndocumentedRouteIsReported(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := testConfig(t)
+	engine, err := New(cfg, stubDeps(cfg, true))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	engine.GET(V1Prefix+"/undocumented", func(c *gin.Context) {})
+
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load document: %v", err)
+	}
+	problems := openapi.Mismatches(doc, engine.Routes())
+	want := "GET " + V1Prefix + "/undocumented is routed but not documented"
+	if len(problems) != 1 || problems[0] != want {
+		t.Errorf("Mismatches = %q, want [%q]", problems, want)
+	}
+}
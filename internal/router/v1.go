@@ -0,0 +1,70 @@
+package router
+
+import (
+	"expense-tracker/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// V1Prefix is where version 1 of the API is mounted.
+const V1Prefix = "/api/v1"
+
+func registerV1(v1 *gin.RouterGroup, deps Deps, m chains) {
+	public := v1.Group("", m.public...)
+	{
+		// login is left out on purpose: replaying it would store issued tokens
+		public.POST("/auth/register", m.idempotency, deps.User.CreateUserHandler)
+		public.POST("/auth/login", deps.User.LogInUserHandler)
+	}
+
+	user := v1.Group("", m.user...)
+	{
+		user.GET("/users/me", deps.User.GetUserHandler)
+		user.GET("/users/me/activity", deps.Audit.GetActivityHandler)
+
+		user.GET("/expenses", deps.Expense.GetAllExpenseHandler)
+		user.POST("/expenses", deps.Expense.AddExpenseHandler)
+		user.GET("/expenses/trash", deps.Expense.GetTrashHandler)
+		if deps.Stream != nil {
+			user.GET("/expenses/stream", deps.Stream.ExpenseStreamHandler)
+			user.GET("/expenses/stream/ws", deps.Stream.ExpenseWebSocketHandler)
+		}
+		user.POST("/expenses/batch", deps.Expense.BatchExpenseHandler)
+		user.GET("/expenses/:id", deps.Expense.GetExpenseByIDHandler)
+		user.PUT("/expenses/:id", deps.Expense.UpdateExpenseHandler)
+		user.PATCH("/expenses/:id", deps.Expense.PatchExpenseHandler)
+		user.DELETE("/expenses/:id", deps.Expense.DeleteExpenseHandler)
+		user.POST("/expenses/:id/restore", deps.Expense.RestoreExpenseHandler)
+		user.GET("/expenses/:id/history", deps.Audit.GetExpenseHistoryHandler)
+
+		user.POST("/sync", deps.Sync.SyncHandler)
+
+		user.GET("/imports", deps.Import.ListImportsHandler)
+		user.POST("/imports", deps.Import.UploadStatementHandler)
+		user.GET("/imports/:id", deps.Import.GetImportHandler)
+		user.PATCH("/imports/:id/candidates/:candidateId", deps.Import.UpdateCandidateHandler)
+		user.POST("/imports/:id/review", deps.Import.ReviewImportHandler)
+
+		user.GET("/rules", deps.Rule.ListRulesHandler)
+		user.POST("/rules", deps.Rule.CreateRuleHandler)
+		user.POST("/rules/apply", deps.Rule.ApplyRulesHandler)
+		user.GET("/rules/:id", deps.Rule.GetRuleHandler)
+		user.PUT("/rules/:id", deps.Rule.UpdateRuleHandler)
+		user.DELETE("/rules/:id", deps.Rule.DeleteRuleHandler)
+
+		user.GET("/budgets", deps.Budget.ListBudgetsHandler)
+		user.PUT("/budgets/:category", deps.Budget.SetBudgetHandler)
+		user.DELETE("/budgets/:category", deps.Budget.DeleteBudgetHandler)
+
+		user.GET("/webhooks", deps.Webhook.ListWebhooksHandler)
+		user.POST("/webhooks", deps.Webhook.CreateWebhookHandler)
+		user.GET("/webhooks/:id", deps.Webhook.GetWebhookHandler)
+		user.DELETE("/webhooks/:id", deps.Webhook.DeleteWebhookHandler)
+		user.GET("/webhooks/:id/deliveries", deps.Webhook.ListDeliveriesHandler)
+		user.POST("/webhooks/:id/deliveries/:deliveryId/retry", deps.Webhook.RetryDeliveryHandler)
+
+		if deps.GraphQL != nil {
+			user.POST("/graphql", middleware.ReadOnly(), deps.GraphQL.QueryHandler)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Package rules evaluates a user's categorization rules against expenses.
+package rules
+
+import (
+	"cmp"
+	"expense-tracker/internal/model"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Weekdays maps the day names rules use to time.Weekday.
+var Weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Subject is what rules look at.
+type Subject struct {
+	Description string
+	Amount      float64
+	// Date decides the weekday, in UTC.
+	Date time.Time
+}
+
+// Outcome is the combined effect of every matching rule. Category is set by
+// the first matching rule that has one; tags and the reimbursable flag
+// accumulate over all of them.
+type Outcome struct {
+	Category     string
+	Tags         []string
+	Reimbursable bool
+	RuleIDs      []int
+}
+
+func (o Outcome) Matched() bool {
+	return len(o.RuleIDs) > 0
+}
+
+// Engine holds compiled rules in the order they run.
+type Engine struct {
+	rules []compiled
+}
+
+type compiled struct {
+	*model.Rule
+	contains string
+	pattern  *regexp.Regexp
+	weekdays map[time.Weekday]bool
+}
+
+// Compile prepares the enabled rules, ordered by priority and then id.
+func Compile(rules []*model.Rule) (*Engine, error) {
+	engine := &Engine{}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		c := compiled{Rule: rule}
+		if rule.DescriptionContains != nil {
+			c.contains = strings.ToLower(*rule.DescriptionContains)
+		}
+		if rule.DescriptionRegex != nil {
+			pattern, err := regexp.Compile(*rule.DescriptionRegex)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %w", rule.ID, err)
+			}
+			c.pattern = pattern
+		}
+		if len(rule.Weekdays) > 0 {
+			c.weekdays = make(map[time.Weekday]bool, len(rule.Weekdays))
+			for _, name := range rule.Weekdays {
+				day, ok := Weekdays[name]
+				if !ok {
+					return nil, fmt.Errorf("rule %d: unknown weekday %q", rule.ID, name)
+				}
+				c.weekdays[day] = true
+			}
+		}
+		engine.rules = append(engine.rules, c)
+	}
+	slices.SortStableFunc(engine.rules, func(a, b compiled) int {
+		return cmp.Or(cmp.Compare(a.Priority, b.Priority), cmp.Compare(a.ID, b.ID))
+	})
+	return engine, nil
+}
+
+func (e *Engine) Evaluate(s Subject) Outcome {
+	var out Outcome
+	for _, rule := range e.rules {
+		if !rule.matches(s) {
+			continue
+		}
+		out.RuleIDs = append(out.RuleIDs, rule.ID)
+		if out.Category == "" && rule.SetCategory != nil {
+			out.Category = *rule.SetCategory
+		}
+		out.Tags = MergeTags(out.Tags, rule.AddTags)
+		out.Reimbursable = out.Reimbursable || rule.MarkReimbursable
+	}
+	return out
+}
+
+func (c compiled) matches(s Subject) bool {
+	if c.contains != "" && !strings.Contains(strings.ToLower(s.Description), c.contains) {
+		return false
+	}
+	if c.pattern != nil && !c.pattern.MatchString(s.Description) {
+		return false
+	}
+	if c.MinAmount != nil && s.Amount < *c.MinAmount {
+		return false
+	}
+	if c.MaxAmount != nil && s.Amount > *c.MaxAmount {
+		return false
+	}
+	if c.weekdays != nil && !c.weekdays[s.Date.UTC().Weekday()] {
+		return false
+	}
+	return true
+}
+
+// Apply sets the outcome on labels. The outcome's category replaces the
+// current one only with override; rules never take a tag or the
+// reimbursable flag away. It reports whether anything changed.
+func (o Outcome) Apply(labels *model.ExpenseLabels, override bool) bool {
+	changed := false
+	if o.Category != "" && (override || labels.Category == "") && labels.Category != o.Category {
+		labels.Category, changed = o.Category, true
+	}
+	if tags := MergeTags(slices.Clone(labels.Tags), o.Tags); len(tags) != len(labels.Tags) {
+		labels.Tags, changed = tags, true
+	}
+	if o.Reimbursable && !labels.Reimbursable {
+		labels.Reimbursable, changed = true, true
+	}
+	return changed
+}
+
+// MergeTags appends the tags of add that tags lacks.
+func MergeTags(tags, add []string) []string {
+	for _, tag := range add {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,253 @@
+package rules
+
+import (
+	"expense-tracker/internal/model"
+	"slices"
+	"testing"
+	"time"
+)
+
+// sunday is 2026-10-18, a Sunday in UTC.
+var sunday = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []*model.Rule
+		subject Subject
+		want    Outcome
+	}{
+		{
+			name: "lower priority runs first",
+			rules: []*model.Rule{
+				{ID: 1, Priority: 20, Enabled: true, SetCategory: ptr("Later")},
+				{ID: 2, Priority: 10, Enabled: true, SetCategory: ptr("First")},
+			},
+			want: Outcome{Category: "First", RuleIDs: []int{2, 1}},
+		},
+		{
+			name: "a priority tie goes to the lower id",
+			rules: []*model.Rule{
+				{ID: 7, Priority: 10, Enabled: true, SetCategory: ptr("Seven")},
+				{ID: 3, Priority: 10, Enabled: true, SetCategory: ptr("Three")},
+			},
+			want: Outcome{Category: "Three", RuleIDs: []int{3, 7}},
+		},
+		{
+			name: "tags and reimbursable accumulate",
+			rules: []*model.Rule{
+				{ID: 1, Enabled: true, AddTags: []string{"travel", "work"}},
+				{ID: 2, Enabled: true, AddTags: []string{"work", "taxi"}, MarkReimbursable: true},
+			},
+			want: Outcome{Tags: []string{"travel", "work", "taxi"}, Reimbursable: true, RuleIDs: []int{1, 2}},
+		},
+		{
+			name: "disabled rules are skipped",
+			rules: []*model.Rule{
+				{ID: 1, Enabled: false, SetCategory: ptr("Off")},
+			},
+			want: Outcome{},
+		},
+		{
+			name:    "substring matches the merchant in any case",
+			rules:   []*model.Rule{{ID: 1, Enabled: true, DescriptionContains: ptr("coffee"), SetCategory: ptr("Food")}},
+			subject: Subject{Description: "Blue Bottle COFFEE #12"},
+			want:    Outcome{Category: "Food", RuleIDs: []int{1}},
+		},
+		{
+			name:    "substring miss",
+			rules:   []*model.Rule{{ID: 1, Enabled: true, DescriptionContains: ptr("coffee"), SetCategory: ptr("Food")}},
+			subject: Subject{Description: "Corner Grocery"},
+			want:    Outcome{},
+		},
+		{
+			name:    "regex matches the merchant",
+			rules:   []*model.Rule{{ID: 1, Enabled: true, DescriptionRegex: ptr(`^(?i)uber\b`), SetCategory: ptr("Transport")}},
+			subject: Subject{Description: "Uber *TRIP 4411"},
+			want:    Outcome{Category: "Transport", RuleIDs: []int{1}},
+		},
+		{
+			name:    "regex is case-sensitive unless it says otherwise",
+			rules:   []*model.Rule{{ID: 1, Enabled: true, DescriptionRegex: ptr(`^uber`), SetCategory: ptr("Transport")}},
+			subject: Subject{Description: "Uber *TRIP 4411"},
+			want:    Outcome{},
+		},
+		{
+			name: "substring and regex must both match",
+			rules: []*model.Rule{{ID: 1, Enabled: true, DescriptionContains: ptr("eats"),
+				DescriptionRegex: ptr(`^(?i)uber`), SetCategory: ptr("Food")}},
+			subject: Subject{Description: "Uber *TRIP 4411"},
+			want:    Outcome{},
+		},
+		{
+			name:    "amount at the minimum",
+			rules:   []*model.Rule{{ID: 1, Enabled: true, MinAmount: ptr(10.0), MaxAmount: ptr(20.0)}},
+			subject: Subject{Amount: 10},
+			want:    Outcome{RuleIDs: []int{1}},
+		},
+		{
+			name:    "amount at the maximum",
+			rules:   []*model.Rule{{ID: 1, Enabled: true, MinAmount: ptr(10.0), MaxAmount: ptr(20.0)}},
+			subject: Subject{Amount: 20},
+			want:    Outcome{RuleIDs: []int{1}},
+		},
+		{
+			name:    "amount just below the minimum",
+			rules:   []*model.Rule{{ID: 1, Enabled: true, MinAmount: ptr(10.0), MaxAmount: ptr(20.0)}},
+			subject: Subject{Amount: 9.99},
+			want:    Outcome{},
+		},
+		{
+			name:    "amount just above the maximum",
+			rules:   []*model.Rule{{ID: 1, Enabled: true, MinAmount: ptr(10.0), MaxAmount: ptr(20.0)}},
+			subject: Subject{Amount: 20.01},
+			want:    Outcome{},
+		},
+		{
+			name:    "weekday matches",
+			rules:   []*model.Rule{{ID: 1, Enabled: true, Weekdays: []string{"saturday", "sunday"}}},
+			subject: Subject{Date: sunday},
+			want:    Outcome{RuleIDs: []int{1}},
+		},
+		{
+			name:    "weekday miss",
+			rules:   []*model.Rule{{ID: 1, Enabled: true, Weekdays: []string{"saturday", "sunday"}}},
+			subject: Subject{Date: sunday.AddDate(0, 0, 1)},
+			want:    Outcome{},
+		},
+		{
+			// Monday 01:00 at UTC+2 is still Sunday in UTC
+			name:    "weekday is taken in UTC",
+			rules:   []*model.Rule{{ID: 1, Enabled: true, Weekdays: []string{"sunday"}}},
+			subject: Subject{Date: time.Date(2026, 10, 19, 1, 0, 0, 0, time.FixedZone("", 2*60*60))},
+			want:    Outcome{RuleIDs: []int{1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := Compile(tt.rules)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			got := engine.Evaluate(tt.subject)
+			if got.Category != tt.want.Category || got.Reimbursable != tt.want.Reimbursable ||
+				!slices.Equal(got.Tags, tt.want.Tags) || !slices.Equal(got.RuleIDs, tt.want.RuleIDs) {
+				t.Errorf("Evaluate(%+v) = %+v, want %+v", tt.subject, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileRejectsBadRules(t *testing.T) {
+	for _, rule := range []*model.Rule{
+		{ID: 1, Enabled: true, DescriptionRegex: ptr(`(`)},
+		{ID: 2, Enabled: true, Weekdays: []string{"someday"}},
+	} {
+		if _, err := Compile([]*model.Rule{rule}); err == nil {
+			t.Errorf("Compile(rule %d) succeeded, want an error", rule.ID)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		outcome     Outcome
+		labels      model.ExpenseLabels
+		override    bool
+		want        model.ExpenseLabels
+		wantChanged bool
+	}{
+		{
+			name:        "an explicit category wins on create",
+			outcome:     Outcome{Category: "Transport"},
+			labels:      model.ExpenseLabels{Category: "Food"},
+			want:        model.ExpenseLabels{Category: "Food"},
+			wantChanged: false,
+		},
+		{
+			name:        "a rule sets a missing category",
+			outcome:     Outcome{Category: "Transport"},
+			labels:      model.ExpenseLabels{},
+			want:        model.ExpenseLabels{Category: "Transport"},
+			wantChanged: true,
+		},
+		{
+			name:        "override replaces the category",
+			outcome:     Outcome{Category: "Transport"},
+			labels:      model.ExpenseLabels{Category: "Food"},
+			override:    true,
+			want:        model.ExpenseLabels{Category: "Transport"},
+			wantChanged: true,
+		},
+		{
+			name:        "override with the same category changes nothing",
+			outcome:     Outcome{Category: "Food"},
+			labels:      model.ExpenseLabels{Category: "Food"},
+			override:    true,
+			want:        model.ExpenseLabels{Category: "Food"},
+			wantChanged: false,
+		},
+		{
+			name:        "tags merge without duplicates",
+			outcome:     Outcome{Tags: []string{"work", "taxi"}},
+			labels:      model.ExpenseLabels{Category: "Food", Tags: []string{"work"}},
+			want:        model.ExpenseLabels{Category: "Food", Tags: []string{"work", "taxi"}},
+			wantChanged: true,
+		},
+		{
+			name:        "reimbursable is never cleared",
+			outcome:     Outcome{},
+			labels:      model.ExpenseLabels{Category: "Food", Reimbursable: true},
+			override:    true,
+			want:        model.ExpenseLabels{Category: "Food", Reimbursable: true},
+			wantChanged: false,
+		},
+		{
+			name:        "reimbursable is set",
+			outcome:     Outcome{Reimbursable: true},
+			labels:      model.ExpenseLabels{Category: "Food"},
+			want:        model.ExpenseLabels{Category: "Food", Reimbursable: true},
+			wantChanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := tt.labels
+			changed := tt.outcome.Apply(&labels, tt.override)
+			if changed != tt.wantChanged || labels.Category != tt.want.Category ||
+				labels.Reimbursable != tt.want.Reimbursable || !slices.Equal(labels.Tags, tt.want.Tags) {
+				t.Errorf("Apply(%+v, %v) = %+v, %v, want %+v, %v",
+					tt.labels, tt.override, labels, changed, tt.want, tt.wantChanged)
+			}
+		})
+	}
+}
+
+// A dry run previews by applying to a copy of the stored labels; the copy
+// must not share the stored tags, even when their slice has spare room.
+func TestApplyLeavesStoredLabels(t *testing.T) {
+	storedTags := make([]string, 1, 4)
+	storedTags[0] = "work"
+	stored := model.ExpenseLabels{Category: "Food", Tags: storedTags}
+
+	preview := stored
+	outcome := Outcome{Category: "Transport", Tags: []string{"taxi"}, Reimbursable: true}
+	if !outcome.Apply(&preview, true) {
+		t.Fatal("Apply reported no change")
+	}
+
+	if stored.Category != "Food" || stored.Reimbursable || !slices.Equal(stored.Tags, []string{"work"}) {
+		t.Errorf("stored labels = %+v, want them unchanged", stored)
+	}
+	if got := storedTags[:2]; got[1] != "" {
+		t.Errorf("stored tags' backing array = %q, want the preview's tags kept out of it", got)
+	}
+	if !slices.Equal(preview.Tags, []string{"work", "taxi"}) || preview.Category != "Transport" {
+		t.Errorf("preview = %+v", preview)
+	}
+}
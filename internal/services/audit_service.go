@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/reqctx"
+	"fmt"
+)
+
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 200
+)
+
+type AuditService struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditService(auditRepo repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+func (s *AuditService) GetExpenseHistoryService(ctx context.Context, expenseID, userID int) ([]*model.AuditEntry, error) {
+	if expenseID <= 0 || userID <= 0 {
+		return nil, errInvalidExpenseID
+	}
+
+	entries, err := s.auditRepo.GetEntityHistory(ctx, model.AuditEntityExpense, expenseID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch expense history: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, ErrExpenseNotFound
+	}
+	return entries, nil
+}
+
+// GetExpenseHistoriesService loads the history of several of the user's
+// expenses in one query, keyed by expense id. Expenses without history, or
+// of another user, are missing from the map.
+func (s *AuditService) GetExpenseHistoriesService(ctx context.Context, expenseIDs []int, userID int) (map[int][]*model.AuditEntry, error) {
+	entries, err := s.auditRepo.GetEntityHistories(ctx, model.AuditEntityExpense, expenseIDs, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch expense histories: %w", err)
+	}
+	histories := make(map[int][]*model.AuditEntry, len(expenseIDs))
+	for _, entry := range entries {
+		histories[entry.EntityID] = append(histories[entry.EntityID], entry)
+	}
+	return histories, nil
+}
+
+// GetActivityService pages through every change to the user's data, newest
+// first. beforeID is the id of the last entry of the previous page, or 0.
+func (s *AuditService) GetActivityService(ctx context.Context, userID int, beforeID int64, limit int) ([]*model.AuditEntry, error) {
+	if userID <= 0 || beforeID < 0 {
+		return nil, apperr.Validation("invalid before", apperr.Field("before", "must not be negative"))
+	}
+	if limit <= 0 {
+		limit = defaultActivityLimit
+	}
+	limit = min(limit, maxActivityLimit)
+
+	entries, err := s.auditRepo.GetOwnerActivity(ctx, userID, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch activity: %w", err)
+	}
+	return entries, nil
+}
+
+// auditChange writes one audit entry through repo, which must be bound to the
+// same transaction as the change itself. A nil actorID marks a system change.
+func auditChange(ctx context.Context, repo repository.AuditRepository, actorID *int, ownerID int, action, entityType string, entityID int, before, after any) error {
+	meta := reqctx.MetaFrom(ctx)
+	entry := &model.AuditEntry{
+		OwnerID:    &ownerID,
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		RequestID:  meta.RequestID,
+		IP:         meta.IP,
+	}
+
+	var err error
+	if entry.Before, err = marshalAuditState(before); err != nil {
+		return err
+	}
+	if entry.After, err = marshalAuditState(after); err != nil {
+		return err
+	}
+
+	if err := repo.CreateAuditEntry(ctx, entry); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+func marshalAuditState(state any) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit state: %w", err)
+	}
+	return data, nil
+}
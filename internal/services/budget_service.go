@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/tracing"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type BudgetService struct {
+	budgetRepo repository.BudgetRepository
+}
+
+func NewBudgetService(budgetRepo repository.BudgetRepository) *BudgetService {
+	return &BudgetService{budgetRepo: budgetRepo}
+}
+
+var ErrBudgetNotFound = apperr.NotFound("budget not found")
+
+func (s *BudgetService) ListBudgetsService(ctx context.Context, userID int) ([]*model.Budget, error) {
+	budgets, err := s.budgetRepo.ListBudgets(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch budgets: %w", err)
+	}
+	return budgets, nil
+}
+
+// SetBudgetService creates or replaces the monthly limit of a category.
+// Going over it emits a budget.exceeded event once per month.
+func (s *BudgetService) SetBudgetService(ctx context.Context, userID int, category string, monthlyLimit float64) (_ *model.Budget, err error) {
+	ctx, span := tracing.Start(ctx, "BudgetService.SetBudgetService")
+	defer func() { tracing.End(span, err) }()
+
+	if strings.TrimSpace(category) == "" {
+		return nil, apperr.Validation("category is required", apperr.Field("category", "is required"))
+	}
+	if monthlyLimit <= 0 {
+		return nil, apperr.Validation("monthly limit must be greater than 0", apperr.Field("monthly_limit", "must be greater than 0"))
+	}
+
+	budget, err := s.budgetRepo.UpsertBudget(ctx, userID, category, monthlyLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save budget: %w", err)
+	}
+	return budget, nil
+}
+
+func (s *BudgetService) DeleteBudgetService(ctx context.Context, userID int, category string) (err error) {
+	ctx, span := tracing.Start(ctx, "BudgetService.DeleteBudgetService")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.budgetRepo.DeleteBudget(ctx, userID, category); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBudgetNotFound
+		}
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"fmt"
+)
+
+// expenseEventTypes are the events pushed to expense streams.
+var expenseEventTypes = []string{
+	model.EventExpenseCreated,
+	model.EventExpenseUpdated,
+	model.EventExpenseDeleted,
+	model.EventExpenseRestored,
+}
+
+// EventService reads back the events recorded in the outbox, for clients
+// following their changes live.
+type EventService struct {
+	outboxRepo repository.OutboxRepository
+}
+
+func NewEventService(outboxRepo repository.OutboxRepository) *EventService {
+	return &EventService{outboxRepo: outboxRepo}
+}
+
+// ListExpenseEventsService returns up to limit of the user's expense events
+// after seq afterSeq, in the order they committed.
+func (s *EventService) ListExpenseEventsService(ctx context.Context, userID int, afterSeq int64, limit int) ([]*model.OutboxEvent, error) {
+	events, err := s.outboxRepo.ListUserEvents(ctx, userID, afterSeq, expenseEventTypes, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch events: %w", err)
+	}
+	return events, nil
+}
+
+// StreamStartService returns the seq a stream starts after. A resuming
+// stream picks up after resumeAfter. A new one, or one resuming from before
+// events were purged, starts after the newest event so it only sees changes
+// made from now on; for the latter reset is set, as the client has missed
+// changes it can no longer be sent and must reload.
+func (s *EventService) StreamStartService(ctx context.Context, userID int, resumeAfter *int64) (after int64, reset bool, err error) {
+	if resumeAfter != nil {
+		purged, err := s.outboxRepo.PurgedThrough(ctx, userID)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to fetch purge mark: %w", err)
+		}
+		if *resumeAfter >= purged {
+			return *resumeAfter, false, nil
+		}
+		reset = true
+	}
+	latest, err := s.outboxRepo.LatestUserEventSeq(ctx, userID)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to fetch latest event: %w", err)
+	}
+	return latest, reset, nil
+}
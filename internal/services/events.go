@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ExpenseEvent is the payload of the expense.* events. Previous is the
+// expense before an update.
+type ExpenseEvent struct {
+	Expense  *model.Expense `json:"expense"`
+	Previous *model.Expense `json:"previous,omitempty"`
+}
+
+// BudgetExceededEvent is the payload of budget.exceeded.
+type BudgetExceededEvent struct {
+	Category     string  `json:"category"`
+	Month        string  `json:"month"`
+	MonthlyLimit float64 `json:"monthly_limit"`
+	Total        float64 `json:"total"`
+}
+
+// recordEvent appends an event to the outbox through repo, which must be
+// bound to the transaction of the change, so the event is published exactly
+// when the change commits.
+func recordEvent(ctx context.Context, repo repository.OutboxRepository, userID int, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	event := &model.OutboxEvent{UserID: userID, Type: eventType, Payload: data}
+	if err := repo.CreateEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// recordExpenseChange records the event for a change to one expense and, when
+// the change added spending to a category, checks that category's budget.
+// before is nil for creates and restores, after is nil for deletes.
+func recordExpenseChange(ctx context.Context, repos repository.Repositories, userID int, eventType string, before, after *model.Expense) error {
+	event := ExpenseEvent{Expense: after}
+	switch {
+	case after == nil:
+		event.Expense = before
+	case before != nil:
+		event.Previous = before
+	}
+	if err := recordEvent(ctx, repos.Outbox, userID, eventType, event); err != nil {
+		return err
+	}
+
+	if after == nil {
+		return nil
+	}
+	added := after.Amount
+	if before != nil && before.Category == after.Category {
+		added -= before.Amount
+	}
+	return checkBudget(ctx, repos, userID, after.Category, after.CreatedAt, added)
+}
+
+// checkBudget records budget.exceeded when adding added to category's total
+// for the month of at took it over the budget. Later changes in a month that
+// is already over budget do not repeat the event.
+func checkBudget(ctx context.Context, repos repository.Repositories, userID int, category string, at time.Time, added float64) error {
+	if added <= 0 {
+		return nil
+	}
+	budget, err := repos.Budgets.GetBudget(ctx, userID, category)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to fetch budget: %w", err)
+	}
+
+	at = at.UTC()
+	from := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	summary, err := repos.Expenses.SummarizeExpenses(ctx, userID, repository.ExpenseFilter{
+		Category: &category,
+		From:     &from,
+		To:       &to,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to total the budget month: %w", err)
+	}
+	if summary.Total <= budget.MonthlyLimit || summary.Total-added > budget.MonthlyLimit {
+		return nil
+	}
+	return recordEvent(ctx, repos.Outbox, userID, model.EventBudgetExceeded, BudgetExceededEvent{
+		Category:     category,
+		Month:        from.Format("2006-01"),
+		MonthlyLimit: budget.MonthlyLimit,
+		Total:        summary.Total,
+	})
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/metrics"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/rules"
+	"expense-tracker/internal/tracing"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxBatchOperations caps how many operations a single batch may contain.
+const MaxBatchOperations = 100
+
+type BatchOpType string
+
+const (
+	BatchCreate       BatchOpType = "create"
+	BatchUpdate       BatchOpType = "update"
+	BatchDelete       BatchOpType = "delete"
+	BatchRecategorize BatchOpType = "recategorize"
+)
+
+// BatchOperation is one entry of a batch request. Which fields are used depends on Op:
+// create needs Amount and takes Category and Description, running the user's
+// rules like AddExpenseService; update needs ExpenseID and at least one of
+// Amount/Category/Description/Tags/Reimbursable, delete needs ExpenseID,
+// recategorize needs Filter and Category.
+// ExpectedVersion optionally guards update and delete like an If-Match header.
+type BatchOperation struct {
+	Op              BatchOpType
+	ExpenseID       int
+	Amount          *float64
+	Category        *string
+	Description     *string
+	Tags            *[]string
+	Reimbursable    *bool
+	Filter          *repository.ExpenseFilter
+	ExpectedVersion *int
+}
+
+// update is the UpdateExpenseInput of an update operation.
+func (op BatchOperation) update() UpdateExpenseInput {
+	return UpdateExpenseInput{
+		Amount:           op.Amount,
+		Category:         op.Category,
+		Description:      op.Description,
+		Tags:             op.Tags,
+		Reimbursable:     op.Reimbursable,
+		ExpectedVersions: exactVersion(op.ExpectedVersion),
+	}
+}
+
+type BatchResult struct {
+	Index    int            `json:"index"`
+	Op       BatchOpType    `json:"op"`
+	Status   string         `json:"status"`
+	Expense  *model.Expense `json:"expense,omitempty"`
+	Affected *int64         `json:"affected,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+const (
+	BatchStatusOK         = "ok"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back"
+	BatchStatusSkipped    = "skipped"
+)
+
+var ErrInvalidBatch = apperr.Validation("batch contains invalid operations")
+
+// BatchError is returned when a batch is rejected or rolled back. Results holds
+// the outcome of every operation so the client can see which one failed.
+type BatchError struct {
+	Results []BatchResult
+	Err     error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch failed: %v", e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// BatchExpenseService validates every operation up front and then applies them
+// all in one transaction: either every operation succeeds or none is persisted.
+func (s *ExpenseService) BatchExpenseService(ctx context.Context, userID int, ops []BatchOperation) (_ []BatchResult, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.BatchExpenseService")
+	defer func() { tracing.End(span, err) }()
+
+	if userID <= 0 {
+		return nil, apperr.Unauthorized("invalid user")
+	}
+	if len(ops) == 0 || len(ops) > MaxBatchOperations {
+		limit := fmt.Sprintf("between 1 and %d operations are allowed", MaxBatchOperations)
+		return nil, apperr.Validation(limit, apperr.Field("operations", limit))
+	}
+
+	results := make([]BatchResult, len(ops))
+	invalid := false
+	for i := range ops {
+		results[i] = BatchResult{Index: i, Op: ops[i].Op, Status: BatchStatusOK}
+		if err := s.validateBatchOperation(&ops[i]); err != nil {
+			results[i].Status = BatchStatusFailed
+			results[i].Error = err.Error()
+			invalid = true
+		}
+	}
+	if invalid {
+		for i := range results {
+			if results[i].Status == BatchStatusOK {
+				results[i].Status = BatchStatusSkipped
+			}
+		}
+		return nil, &BatchError{Results: results, Err: ErrInvalidBatch}
+	}
+
+	creates := slices.ContainsFunc(ops, func(op BatchOperation) bool { return op.Op == BatchCreate })
+	failed := -1
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		failed = -1 // reset on retry
+		var engine *rules.Engine
+		if creates {
+			var err error
+			if engine, err = loadRules(ctx, repos.Rules, userID); err != nil {
+				return err
+			}
+		}
+		for i, op := range ops {
+			if err := s.applyBatchOperation(ctx, repos, engine, userID, op, &results[i]); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if failed < 0 {
+			// commit itself failed
+			return nil, fmt.Errorf("failed to apply batch: %w", err)
+		}
+		for i := range results {
+			results[i].Expense = nil
+			results[i].Affected = nil
+			switch {
+			case i < failed:
+				results[i].Status = BatchStatusRolledBack
+			case i == failed:
+				results[i].Status = BatchStatusFailed
+				results[i].Error = "internal error"
+				if appErr := apperr.From(err); appErr.Kind != apperr.KindInternal {
+					results[i].Error = appErr.Message
+				}
+			default:
+				results[i].Status = BatchStatusSkipped
+			}
+		}
+		return nil, &BatchError{Results: results, Err: err}
+	}
+	for _, op := range ops {
+		if op.Op == BatchCreate {
+			metrics.ExpensesCreated.Inc()
+		}
+	}
+	return results, nil
+}
+
+// validateBatchOperation checks op and normalizes the fields it sets.
+func (s *ExpenseService) validateBatchOperation(op *BatchOperation) error {
+	switch op.Op {
+	case BatchCreate:
+		if op.Amount == nil {
+			return apperr.Validation("amount is required", apperr.Field("amount", "is required"))
+		}
+		if err := s.ValidatePrice(*op.Amount); err != nil {
+			return err
+		}
+		if op.Description != nil {
+			if err := validateDescription(strings.TrimSpace(*op.Description)); err != nil {
+				return err
+			}
+		}
+		if op.Category != nil {
+			return s.validateCategory(*op.Category)
+		}
+		return nil
+
+	case BatchUpdate:
+		if op.ExpenseID <= 0 {
+			return errInvalidExpenseID
+		}
+		update := op.update()
+		if update.isEmpty() {
+			return apperr.Validation("an update must set at least one field")
+		}
+		if err := s.normalizeUpdate(&update); err != nil {
+			return err
+		}
+		op.Description, op.Tags = update.Description, update.Tags
+		return nil
+
+	case BatchDelete:
+		if op.ExpenseID <= 0 {
+			return errInvalidExpenseID
+		}
+		return nil
+
+	case BatchRecategorize:
+		if op.Filter == nil || op.Filter.IsEmpty() {
+			return apperr.Validation("filter must have at least one criterion", apperr.Field("filter", "must have at least one criterion"))
+		}
+		if op.Category == nil {
+			return apperr.Validation("category is required", apperr.Field("category", "is required"))
+		}
+		return s.validateCategory(*op.Category)
+	}
+	return apperr.Validation(fmt.Sprintf("unknown operation %q", op.Op), apperr.Field("op", "is not a known operation"))
+}
+
+// applyBatchOperation applies op inside the batch's transaction. engine holds
+// the user's rules when the batch creates expenses.
+func (s *ExpenseService) applyBatchOperation(ctx context.Context, repos repository.Repositories, engine *rules.Engine, userID int, op BatchOperation, result *BatchResult) error {
+	switch op.Op {
+	case BatchCreate:
+		expense := &model.Expense{Amount: *op.Amount}
+		if op.Category != nil {
+			expense.Category = *op.Category
+		}
+		if op.Description != nil {
+			expense.Description = strings.TrimSpace(*op.Description)
+		}
+		if err := labelNewExpense(engine, expense); err != nil {
+			return err
+		}
+		expense, err := repos.Expenses.CreateDetailedExpense(ctx, userID, expense)
+		if err != nil {
+			return err
+		}
+		result.Expense = expense
+		if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionCreate, model.AuditEntityExpense, expense.ID, nil, expense); err != nil {
+			return err
+		}
+		return recordExpenseChange(ctx, repos, userID, model.EventExpenseCreated, nil, expense)
+
+	case BatchUpdate:
+		existing, err := lockExpense(ctx, repos.Expenses, op.ExpenseID, userID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(existing, exactVersion(op.ExpectedVersion)); err != nil {
+			return err
+		}
+		before := *existing
+		op.update().applyTo(existing)
+		expense, err := repos.Expenses.UpdateExpense(ctx, op.ExpenseID, userID, existing)
+		if err != nil {
+			return err
+		}
+		result.Expense = expense
+		if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionUpdate, model.AuditEntityExpense, expense.ID, &before, expense); err != nil {
+			return err
+		}
+		return recordExpenseChange(ctx, repos, userID, model.EventExpenseUpdated, &before, expense)
+
+	case BatchDelete:
+		existing, err := lockExpense(ctx, repos.Expenses, op.ExpenseID, userID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(existing, exactVersion(op.ExpectedVersion)); err != nil {
+			return err
+		}
+		if err := repos.Expenses.DeleteExpense(ctx, op.ExpenseID, userID); err != nil {
+			return err
+		}
+		if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionDelete, model.AuditEntityExpense, existing.ID, existing, nil); err != nil {
+			return err
+		}
+		return recordExpenseChange(ctx, repos, userID, model.EventExpenseDeleted, existing, nil)
+
+	case BatchRecategorize:
+		changes, err := repos.Expenses.RecategorizeExpenses(ctx, userID, *op.Filter, *op.Category)
+		if err != nil {
+			return err
+		}
+		// every change is in place before the budget is checked, so add up
+		// what moved into the category per month and check each month once
+		movedIn := map[time.Time]float64{}
+		for _, change := range changes {
+			if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionRecategorize, model.AuditEntityExpense, change.After.ID, change.Before, change.After); err != nil {
+				return err
+			}
+			if err := recordEvent(ctx, repos.Outbox, userID, model.EventExpenseUpdated, ExpenseEvent{Expense: change.After, Previous: change.Before}); err != nil {
+				return err
+			}
+			if change.Before.Category != change.After.Category {
+				at := change.After.CreatedAt.UTC()
+				movedIn[time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)] += change.After.Amount
+			}
+		}
+		for month, added := range movedIn {
+			if err := checkBudget(ctx, repos, userID, *op.Category, month, added); err != nil {
+				return err
+			}
+		}
+		affected := int64(len(changes))
+		result.Affected = &affected
+	}
+	return nil
+}
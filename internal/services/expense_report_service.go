@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/tracing"
+	"fmt"
+)
+
+const (
+	DefaultExpensePageSize = 20
+	MaxExpensePageSize     = 100
+)
+
+// ExpensePage is one page of ListExpensesService. HasNextPage reports whether
+// more expenses follow the last one.
+type ExpensePage struct {
+	Expenses    []*model.Expense
+	HasNextPage bool
+}
+
+// ListExpensesService pages through the user's live expenses matching filter,
+// newest first. after is the position of the last expense of the previous
+// page, or nil; limit defaults to DefaultExpensePageSize.
+func (s *ExpenseService) ListExpensesService(ctx context.Context, userID int, filter repository.ExpenseFilter, after *repository.ExpenseCursor, limit int) (_ *ExpensePage, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.ListExpensesService")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	if limit < 0 || limit > MaxExpensePageSize {
+		return nil, apperr.Validation("invalid page size", apperr.Field("first", fmt.Sprintf("must be between 0 and %d", MaxExpensePageSize)))
+	}
+	if limit == 0 {
+		limit = DefaultExpensePageSize
+	}
+
+	// one extra row tells whether there is a next page
+	expenses, err := s.expenseRepo.ListExpenses(ctx, userID, filter, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expenses: %w", err)
+	}
+	page := &ExpensePage{Expenses: expenses}
+	if len(expenses) > limit {
+		page.Expenses = expenses[:limit]
+		page.HasNextPage = true
+	}
+	return page, nil
+}
+
+func (s *ExpenseService) CountExpensesService(ctx context.Context, userID int, filter repository.ExpenseFilter) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.CountExpensesService")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateFilter(filter); err != nil {
+		return 0, err
+	}
+	count, err := s.expenseRepo.CountExpenses(ctx, userID, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count expenses: %w", err)
+	}
+	return count, nil
+}
+
+func (s *ExpenseService) SummaryService(ctx context.Context, userID int, filter repository.ExpenseFilter) (_ *model.ExpenseSummary, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.SummaryService")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	summary, err := s.expenseRepo.SummarizeExpenses(ctx, userID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize expenses: %w", err)
+	}
+	return summary, nil
+}
+
+// CategoryTotalsService aggregates the user's expenses per category. A
+// non-nil categories restricts the result to those categories.
+func (s *ExpenseService) CategoryTotalsService(ctx context.Context, userID int, filter repository.ExpenseFilter, categories []string) (_ []*model.CategoryTotal, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.CategoryTotalsService")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	totals, err := s.expenseRepo.TotalsByCategory(ctx, userID, filter, categories)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total expenses by category: %w", err)
+	}
+	return totals, nil
+}
+
+func (s *ExpenseService) MonthlyTotalsService(ctx context.Context, userID int, filter repository.ExpenseFilter) (_ []*model.MonthlyTotal, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.MonthlyTotalsService")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	totals, err := s.expenseRepo.TotalsByMonth(ctx, userID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to total expenses by month: %w", err)
+	}
+	return totals, nil
+}
+
+func validateFilter(filter repository.ExpenseFilter) error {
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return apperr.Validation("invalid filter", apperr.Field("filter", "max amount must not be less than min amount"))
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return apperr.Validation("invalid filter", apperr.Field("filter", "to must be after from"))
+	}
+	return nil
+}
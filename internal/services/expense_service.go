@@ -3,74 +3,203 @@ package services
 import (
 	"context"
 	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/metrics"
 	"expense-tracker/internal/model"
 	"expense-tracker/internal/repository"
+	"expense-tracker/internal/tracing"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
 
 type ExpenseService struct {
 	expenseRepo repository.ExpenseRepository
+	tx          *repository.TxManager
 }
 
-func NewExpenseService(expenseRepo repository.ExpenseRepository) *ExpenseService {
-	return &ExpenseService{expenseRepo: expenseRepo}
+func NewExpenseService(expenseRepo repository.ExpenseRepository, tx *repository.TxManager) *ExpenseService {
+	return &ExpenseService{expenseRepo: expenseRepo, tx: tx}
 }
 
+// UpdateExpenseInput changes the fields that are non-nil. A non-nil Tags
+// replaces the expense's tags; an empty list clears them.
 type UpdateExpenseInput struct {
-	Amount   *float64
-	Category *string
+	Amount       *float64
+	Category     *string
+	Description  *string
+	Tags         *[]string
+	Reimbursable *bool
+	// ExpectedVersions, when set, must hold the stored version or the update
+	// fails with ErrVersionMismatch.
+	ExpectedVersions Versions
 }
 
-var ErrExpenseNotFound = errors.New("expense not found") // from GetExpenseByIDService , gotta show this error in handler and dont wanna introduce pgx in handler so.
+// Versions is the precondition of an If-Match header: the versions a write
+// was based on. Nil matches any version; an empty, non-nil list matches none.
+type Versions []int
+
+// exactVersion is the precondition of a single optional expected version.
+func exactVersion(v *int) Versions {
+	if v == nil {
+		return nil
+	}
+	return Versions{*v}
+}
+
+const maxDescriptionLength = 500
+
+var ErrExpenseNotFound = apperr.NotFound("expense not found") // from GetExpenseByIDService , gotta show this error in handler and dont wanna introduce pgx in handler so.
+
+var ErrVersionMismatch = apperr.New(apperr.KindPreconditionFailed, "expense was modified by another request")
+
+var errInvalidExpenseID = apperr.Validation("invalid expense id", apperr.Field("id", "must be a positive integer"))
+
+// AddExpenseService records an expense and runs the user's rules on it. The
+// rules add tags and the reimbursable flag, and pick the category when none
+// is given; an explicit category always wins.
+func (s *ExpenseService) AddExpenseService(ctx context.Context, userID int, amount float64, category, description string) (_ *model.Expense, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.AddExpenseService")
+	defer func() { tracing.End(span, err) }()
 
-func (s *ExpenseService) AddExpenseService(ctx context.Context, userID int, amount float64, category string) (*model.Expense, error) {
 	if err := s.ValidatePrice(amount); err != nil {
 		return nil, err
 	}
 
-	if err := s.validateCategory(category); err != nil {
+	if category != "" {
+		if err := s.validateCategory(category); err != nil {
+			return nil, err
+		}
+	}
+	description = strings.TrimSpace(description)
+	if err := validateDescription(description); err != nil {
 		return nil, err
 	}
 	// call repo
 
-	expense, err := s.expenseRepo.CreateExpense(ctx, userID, amount, category)
-
+	var expense *model.Expense
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		engine, err := loadRules(ctx, repos.Rules, userID)
+		if err != nil {
+			return err
+		}
+		expense = &model.Expense{Amount: amount, Category: category, Description: description}
+		if err := labelNewExpense(engine, expense); err != nil {
+			return err
+		}
+		expense, err = repos.Expenses.CreateDetailedExpense(ctx, userID, expense)
+		if err != nil {
+			return err
+		}
+		if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionCreate, model.AuditEntityExpense, expense.ID, nil, expense); err != nil {
+			return err
+		}
+		return recordExpenseChange(ctx, repos, userID, model.EventExpenseCreated, nil, expense)
+	})
 	if err != nil {
 		return nil, err
 	}
+	metrics.ExpensesCreated.Inc()
 	return expense, nil
 }
 
 func (s *ExpenseService) ValidatePrice(amount float64) error {
 	if amount <= 0 {
-		return errors.New("amount must be greater than 0")
+		return apperr.Validation("amount must be greater than 0", apperr.Field("amount", "must be greater than 0"))
 	}
 	return nil
 }
 
 func (s *ExpenseService) validateCategory(category string) error {
-	if category == " " {
-		return errors.New("category is required")
+	if strings.TrimSpace(category) == "" {
+		return apperr.Validation("category is required", apperr.Field("category", "is required"))
+	}
+	return nil
+}
+
+// normalizeUpdate validates the fields input sets, trims the description and
+// normalizes the tags the way rules store them.
+func (s *ExpenseService) normalizeUpdate(input *UpdateExpenseInput) error {
+	if input.Amount != nil {
+		if err := s.ValidatePrice(*input.Amount); err != nil {
+			return err
+		}
+	}
+	if input.Category != nil {
+		if err := s.validateCategory(*input.Category); err != nil {
+			return err
+		}
+	}
+	if input.Description != nil {
+		description := strings.TrimSpace(*input.Description)
+		if err := validateDescription(description); err != nil {
+			return err
+		}
+		input.Description = &description
+	}
+	if input.Tags != nil {
+		tags, fields := normalizeTags(*input.Tags, "tags")
+		if len(fields) > 0 {
+			return apperr.Validation("invalid tags", fields...)
+		}
+		input.Tags = &tags
+	}
+	return nil
+}
+
+// isEmpty reports whether input changes nothing.
+func (input UpdateExpenseInput) isEmpty() bool {
+	return input.Amount == nil && input.Category == nil && input.Description == nil && input.Tags == nil && input.Reimbursable == nil
+}
+
+// applyTo copies the fields input sets onto e.
+func (input UpdateExpenseInput) applyTo(e *model.Expense) {
+	if input.Amount != nil {
+		e.Amount = *input.Amount
+	}
+	if input.Category != nil {
+		e.Category = *input.Category
+	}
+	if input.Description != nil {
+		e.Description = *input.Description
+	}
+	if input.Tags != nil {
+		e.Tags = *input.Tags
+	}
+	if input.Reimbursable != nil {
+		e.Reimbursable = *input.Reimbursable
+	}
+}
+
+func validateDescription(description string) error {
+	if len(description) > maxDescriptionLength {
+		return apperr.Validation("description is too long", apperr.Field("description", fmt.Sprintf("must be at most %d characters long", maxDescriptionLength)))
 	}
 	return nil
 }
 
-func (s *ExpenseService) GetAllExpenseService(ctx context.Context, userID int) ([]*model.Expense, error) {
+func (s *ExpenseService) GetAllExpenseService(ctx context.Context, userID int) (_ []*model.Expense, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.GetAllExpenseService")
+	defer func() { tracing.End(span, err) }()
+
 	// call repo
 
 	expenses, err := s.expenseRepo.GetAllExpense(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch expenses: %W", err)
+		return nil, fmt.Errorf("failed to fetch expenses: %w", err)
 	}
 	return expenses, nil
 }
 
-func (s *ExpenseService) GetExpenseByIDService(ctx context.Context, expenseId, userID int) (*model.Expense, error) {
+func (s *ExpenseService) GetExpenseByIDService(ctx context.Context, expenseId, userID int) (_ *model.Expense, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.GetExpenseByIDService")
+	defer func() { tracing.End(span, err) }()
 
 	if expenseId <= 0 || userID <= 0 {
-		return nil, errors.New("invalid id")
+		return nil, errInvalidExpenseID
 	}
 	// call repo
 
@@ -79,67 +208,186 @@ func (s *ExpenseService) GetExpenseByIDService(ctx context.Context, expenseId, u
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrExpenseNotFound
 		}
-		return nil, errors.New("failed to fetch expense")
+		return nil, fmt.Errorf("failed to fetch expense: %w", err)
 	}
 	return expense, nil
 }
 
-func (s *ExpenseService) UpdateExpenseService(ctx context.Context, expenseID, userID int, input UpdateExpenseInput) (*model.Expense, error) {
+func (s *ExpenseService) UpdateExpenseService(ctx context.Context, expenseID, userID int, input UpdateExpenseInput) (_ *model.Expense, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.UpdateExpenseService")
+	defer func() { tracing.End(span, err) }()
 
 	if expenseID <= 0 || userID <= 0 {
-		return nil, errors.New("invalid ID")
+		return nil, errInvalidExpenseID
+	}
+
+	if input.isEmpty() {
+		// a write that changes nothing would still bump the version
+		return nil, apperr.Validation("nothing to update", apperr.Field("amount", "set at least one of amount, category, description, tags and reimbursable"))
 	}
+	if err := s.normalizeUpdate(&input); err != nil {
+		return nil, err
+	}
+
+	var updatedExpense *model.Expense
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		// lock the existing expense so a concurrent update can't slip in between read and write
+		existing, err := lockExpense(ctx, repos.Expenses, expenseID, userID)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(existing, input.ExpectedVersions); err != nil {
+			return err
+		}
+
+		before := *existing
+		input.applyTo(existing)
+		// repo call
 
-	// fetch existing expense
-	existing, err := s.expenseRepo.GetExpenseByID(ctx, expenseID, userID)
+		updatedExpense, err = repos.Expenses.UpdateExpense(ctx, expenseID, userID, existing)
+		if err != nil {
+			return err
+		}
+		if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionUpdate, model.AuditEntityExpense, expenseID, &before, updatedExpense); err != nil {
+			return err
+		}
+		return recordExpenseChange(ctx, repos, userID, model.EventExpenseUpdated, &before, updatedExpense)
+	})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrExpenseNotFound
+		if errors.Is(err, ErrExpenseNotFound) || errors.Is(err, ErrVersionMismatch) {
+			return nil, err
 		}
-		return nil, errors.New("failed to fetch expense")
+		return nil, fmt.Errorf("failed to update expense: %w", err)
 	}
+	return updatedExpense, nil
+}
 
-	if input.Amount != nil {
-		if *input.Amount <= 0 {
-			return nil, errors.New("amount must be greater than 0")
-		}
-		existing.Amount = *input.Amount
+// DeleteExpenseService moves the expense to the trash. Non-nil expected
+// versions must hold the stored version or ErrVersionMismatch is returned.
+func (s *ExpenseService) DeleteExpenseService(ctx context.Context, expenseID, userID int, expected Versions) (err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.DeleteExpenseService")
+	defer func() { tracing.End(span, err) }()
+
+	if expenseID <= 0 || userID <= 0 {
+		return errInvalidExpenseID
 	}
 
-	if input.Category != nil {
-		if *input.Category == "" {
-			return nil, errors.New("category can not be empty")
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		// check if expense exists
+
+		existing, err := lockExpense(ctx, repos.Expenses, expenseID, userID)
+		if err != nil {
+			return err
 		}
-		existing.Category = *input.Category
+		if err := checkVersion(existing, expected); err != nil {
+			return err
+		}
+		// call repo
+
+		if err := repos.Expenses.DeleteExpense(ctx, existing.ID, existing.UserID); err != nil {
+			return err
+		}
+		if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionDelete, model.AuditEntityExpense, existing.ID, existing, nil); err != nil {
+			return err
+		}
+		return recordExpenseChange(ctx, repos, userID, model.EventExpenseDeleted, existing, nil)
+	})
+	if err != nil {
+		if errors.Is(err, ErrExpenseNotFound) || errors.Is(err, ErrVersionMismatch) {
+			return err
+		}
+		return fmt.Errorf("failed to delete expense %w", err)
 	}
-	// repo call
+	return nil
+}
+
+func (s *ExpenseService) GetTrashService(ctx context.Context, userID int) (_ []*model.Expense, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.GetTrashService")
+	defer func() { tracing.End(span, err) }()
 
-	updatedExpense, err := s.expenseRepo.UpdateExpense(ctx, expenseID, userID, existing.Amount, existing.Category)
+	// call repo
+
+	expenses, err := s.expenseRepo.GetDeletedExpenses(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update expense: %w", err)
+		return nil, fmt.Errorf("failed to fetch deleted expenses: %w", err)
 	}
-	return updatedExpense, nil
+	return expenses, nil
 }
 
-func (s *ExpenseService) DeleteExpenseService(ctx context.Context, expenseID, userID int) error {
+func (s *ExpenseService) RestoreExpenseService(ctx context.Context, expenseID, userID int) (_ *model.Expense, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.RestoreExpenseService")
+	defer func() { tracing.End(span, err) }()
+
 	if expenseID <= 0 || userID <= 0 {
-		return errors.New("invalid id")
+		return nil, errInvalidExpenseID
 	}
 
-	// check if expense exists
-
-	existing, err := s.expenseRepo.GetExpenseByID(ctx, expenseID, userID)
+	// only expenses that are in the trash can be restored
+	var expense *model.Expense
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		var before *model.Expense
+		var err error
+		before, expense, err = repos.Expenses.RestoreExpense(ctx, expenseID, userID)
+		if err != nil {
+			return err
+		}
+		// the audit keeps the trashed row so the log shows what came back from where
+		if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionRestore, model.AuditEntityExpense, expenseID, before, expense); err != nil {
+			return err
+		}
+		return recordExpenseChange(ctx, repos, userID, model.EventExpenseRestored, nil, expense)
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrExpenseNotFound
+			return nil, ErrExpenseNotFound
 		}
-		return fmt.Errorf("failed to fetch expense %w", err)
+		return nil, fmt.Errorf("failed to restore expense %w", err)
 	}
-	// call repo
+	return expense, nil
+}
 
-	err = s.expenseRepo.DeleteExpense(ctx, existing.ID, existing.UserID)
+// PurgeTrashService permanently deletes expenses that have been in the trash longer than retention.
+func (s *ExpenseService) PurgeTrashService(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "ExpenseService.PurgeTrashService")
+	defer func() { tracing.End(span, err) }()
+
+	var purged []*model.Expense
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		var err error
+		purged, err = repos.Expenses.PurgeDeletedExpenses(ctx, time.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+		for _, expense := range purged {
+			if err := auditChange(ctx, repos.Audit, nil, expense.UserID, model.AuditActionPurge, model.AuditEntityExpense, expense.ID, expense, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete expense %w", err)
+		return 0, fmt.Errorf("failed to purge trash %w", err)
+	}
+	return int64(len(purged)), nil
+}
+
+// lockExpense loads a live expense with a row lock held for the rest of the
+// transaction, mapping a missing row to ErrExpenseNotFound.
+func lockExpense(ctx context.Context, repo repository.ExpenseRepository, expenseID, userID int) (*model.Expense, error) {
+	expense, err := repo.GetExpenseByIDForUpdate(ctx, expenseID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrExpenseNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch expense %w", err)
+	}
+	return expense, nil
+}
+
+// checkVersion compares the stored version against the one the client last saw.
+func checkVersion(expense *model.Expense, expected Versions) error {
+	if expected != nil && !slices.Contains(expected, expense.Version) {
+		return ErrVersionMismatch
 	}
 	return nil
 }
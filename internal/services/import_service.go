@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/metrics"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/rules"
+	"expense-tracker/internal/statement"
+	"expense-tracker/internal/tracing"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultImportLimit = 20
+	maxImportLimit     = 100
+	// maxImportDebits bounds the candidates one upload creates, and so the
+	// size of the review list and of the upload transaction.
+	maxImportDebits    = 5000
+	maxReviewDecisions = 500
+	maxFilenameLength  = 255
+)
+
+// Decisions a review can make about a pending candidate.
+const (
+	ReviewAccept  = "accept"
+	ReviewDiscard = "discard"
+)
+
+var (
+	ErrImportNotFound    = apperr.NotFound("import not found")
+	ErrCandidateNotFound = apperr.NotFound("candidate not found")
+)
+
+// CandidateEdit changes the fields that are non-nil.
+type CandidateEdit struct {
+	Amount   *float64
+	Category *string
+}
+
+// ReviewDecision accepts or discards one candidate. An accept may edit the
+// candidate on the way; it needs a category, given here or set earlier.
+type ReviewDecision struct {
+	CandidateID int64
+	Action      string
+	CandidateEdit
+}
+
+type UploadResult struct {
+	Import *model.StatementImport
+	// Duplicates are debits already imported, from this file or an earlier one.
+	Duplicates int
+	// Credits are incoming payments, which are not expenses.
+	Credits int
+}
+
+type ReviewResult struct {
+	Candidates []*model.ImportCandidate
+	// Expenses are the ones the accepted candidates became.
+	Expenses []*model.Expense
+}
+
+// ImportService turns bank statements into expenses in two steps: an upload
+// stores each new debit as a pending candidate, its category suggested by
+// the user's rules or history, and a review accepts or discards them.
+// Candidates are deduplicated by account and FITID for good, so a discarded
+// transaction does not come back with the next statement.
+type ImportService struct {
+	importRepo repository.ImportRepository
+	tx         *repository.TxManager
+}
+
+func NewImportService(importRepo repository.ImportRepository, tx *repository.TxManager) *ImportService {
+	return &ImportService{importRepo: importRepo, tx: tx}
+}
+
+func (s *ImportService) UploadStatementService(ctx context.Context, userID int, filename string, data []byte) (_ *UploadResult, err error) {
+	ctx, span := tracing.Start(ctx, "ImportService.UploadStatementService")
+	defer func() { tracing.End(span, err) }()
+
+	statements, err := statement.Parse(data)
+	if err != nil {
+		if errors.Is(err, statement.ErrUnknownFormat) {
+			return nil, apperr.New(apperr.KindUnprocessable, "the file is not an OFX, QFX or CAMT.053 statement")
+		}
+		return nil, apperr.New(apperr.KindUnprocessable, fmt.Sprintf("the statement could not be read: %v", err))
+	}
+
+	var debits []*model.ImportCandidate
+	credits := 0
+	for _, stmt := range statements {
+		for _, txn := range stmt.Transactions {
+			// amounts are cents in the database; anything that rounds to
+			// nothing is not worth reviewing
+			amount := math.Round(-txn.Amount*100) / 100
+			if amount <= 0 {
+				credits++
+				continue
+			}
+			debits = append(debits, &model.ImportCandidate{
+				Account:  stmt.Account,
+				FITID:    txn.FITID,
+				PostedAt: txn.PostedAt,
+				Amount:   amount,
+				Currency: txn.Currency,
+				Payee:    txn.Payee,
+				Memo:     txn.Memo,
+			})
+		}
+	}
+	if len(debits) > maxImportDebits {
+		return nil, apperr.Validation("statement too large", apperr.Field("file", fmt.Sprintf("must have at most %d debits; split it by date", maxImportDebits)))
+	}
+
+	filename = strings.TrimSpace(filename)
+	if filename == "" {
+		filename = "statement"
+	}
+	if len(filename) > maxFilenameLength {
+		filename = filename[:maxFilenameLength]
+	}
+
+	var result *UploadResult
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		imp, err := repos.Imports.CreateImport(ctx, userID, filename, statements[0].Format)
+		if err != nil {
+			return err
+		}
+		result = &UploadResult{Import: imp, Credits: credits}
+		if err := suggestCategories(ctx, repos, userID, debits); err != nil {
+			return err
+		}
+		for _, candidate := range debits {
+			candidate.ImportID = imp.ID
+			added, err := repos.Imports.AddCandidate(ctx, userID, candidate)
+			if err != nil {
+				return err
+			}
+			if added {
+				imp.Pending++
+			} else {
+				result.Duplicates++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store import: %w", err)
+	}
+	return result, nil
+}
+
+func (s *ImportService) ListImportsService(ctx context.Context, userID int, beforeID int, limit int) ([]*model.StatementImport, error) {
+	if beforeID < 0 {
+		return nil, apperr.Validation("invalid before", apperr.Field("before", "must not be negative"))
+	}
+	if limit <= 0 {
+		limit = defaultImportLimit
+	}
+	limit = min(limit, maxImportLimit)
+
+	imports, err := s.importRepo.ListImports(ctx, userID, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch imports: %w", err)
+	}
+	return imports, nil
+}
+
+// GetImportService returns the import with its candidates, optionally only
+// those in status.
+func (s *ImportService) GetImportService(ctx context.Context, importID, userID int, status string) (_ *model.StatementImport, _ []*model.ImportCandidate, err error) {
+	ctx, span := tracing.Start(ctx, "ImportService.GetImportService")
+	defer func() { tracing.End(span, err) }()
+
+	switch status {
+	case "", model.CandidatePending, model.CandidateAccepted, model.CandidateDiscarded:
+	default:
+		return nil, nil, apperr.Validation("invalid status", apperr.Field("status", "must be pending, accepted or discarded"))
+	}
+
+	imp, err := s.importRepo.GetImport(ctx, importID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrImportNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to fetch import: %w", err)
+	}
+	candidates, err := s.importRepo.ListCandidates(ctx, importID, status)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch candidates: %w", err)
+	}
+	return imp, candidates, nil
+}
+
+// UpdateCandidateService edits a candidate that is still pending.
+func (s *ImportService) UpdateCandidateService(ctx context.Context, importID, userID int, candidateID int64, edit CandidateEdit) (_ *model.ImportCandidate, err error) {
+	ctx, span := tracing.Start(ctx, "ImportService.UpdateCandidateService")
+	defer func() { tracing.End(span, err) }()
+
+	if fields := validateCandidateEdit(edit, ""); len(fields) > 0 {
+		return nil, apperr.Validation("invalid candidate", fields...)
+	}
+	if edit.Amount == nil && edit.Category == nil {
+		return nil, apperr.Validation("nothing to update", apperr.Field("amount", "set amount or category"))
+	}
+
+	var updated *model.ImportCandidate
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		candidate, err := lockCandidate(ctx, repos.Imports, candidateID, importID, userID)
+		if err != nil {
+			return err
+		}
+		if candidate.Status != model.CandidatePending {
+			return apperr.Conflict(fmt.Sprintf("candidate was already %s", candidate.Status))
+		}
+		applyCandidateEdit(candidate, edit)
+		updated, err = repos.Imports.UpdateCandidate(ctx, candidate)
+		return err
+	})
+	if err != nil {
+		if apperr.KindOf(err) != apperr.KindInternal {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update candidate: %w", err)
+	}
+	return updated, nil
+}
+
+// ReviewImportService applies every decision or none. Each accepted
+// candidate becomes an expense dated when the bank posted it, described by
+// its payee and tagged by the user's rules.
+func (s *ImportService) ReviewImportService(ctx context.Context, importID, userID int, decisions []ReviewDecision) (_ *ReviewResult, err error) {
+	ctx, span := tracing.Start(ctx, "ImportService.ReviewImportService")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateReviewDecisions(decisions); err != nil {
+		return nil, err
+	}
+	if _, err := s.importRepo.GetImport(ctx, importID, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrImportNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch import: %w", err)
+	}
+
+	var result *ReviewResult
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		result = &ReviewResult{}
+		engine, err := loadRules(ctx, repos.Rules, userID)
+		if err != nil {
+			return err
+		}
+		for i, decision := range decisions {
+			candidate, err := lockCandidate(ctx, repos.Imports, decision.CandidateID, importID, userID)
+			if err != nil {
+				return err
+			}
+			if candidate.Status != model.CandidatePending {
+				return apperr.Conflict(fmt.Sprintf("candidate %d was already %s", candidate.ID, candidate.Status))
+			}
+			applyCandidateEdit(candidate, decision.CandidateEdit)
+
+			candidate.Status = model.CandidateDiscarded
+			if decision.Action == ReviewAccept {
+				if candidate.Category == nil {
+					return apperr.Validation("category is required",
+						apperr.Field(fmt.Sprintf("decisions[%d].category", i), "is required to accept a candidate without one"))
+				}
+				// the category is settled by now; rules still add tags and the flag
+				expense := &model.Expense{
+					Amount:      candidate.Amount,
+					Category:    *candidate.Category,
+					Description: candidateDescription(candidate),
+					CreatedAt:   candidate.PostedAt,
+				}
+				if err := labelNewExpense(engine, expense); err != nil {
+					return err
+				}
+				expense, err = repos.Expenses.CreateDetailedExpense(ctx, userID, expense)
+				if err != nil {
+					return err
+				}
+				if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionCreate, model.AuditEntityExpense, expense.ID, nil, expense); err != nil {
+					return err
+				}
+				if err := recordExpenseChange(ctx, repos, userID, model.EventExpenseCreated, nil, expense); err != nil {
+					return err
+				}
+				candidate.Status, candidate.ExpenseID = model.CandidateAccepted, &expense.ID
+				result.Expenses = append(result.Expenses, expense)
+			}
+
+			updated, err := repos.Imports.UpdateCandidate(ctx, candidate)
+			if err != nil {
+				return err
+			}
+			result.Candidates = append(result.Candidates, updated)
+		}
+		return nil
+	})
+	if err != nil {
+		if apperr.KindOf(err) != apperr.KindInternal {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to review import: %w", err)
+	}
+	metrics.ExpensesCreated.Add(float64(len(result.Expenses)))
+	return result, nil
+}
+
+// suggestCategories fills in the category of each candidate from the first
+// matching rule or, failing that, from the category the user filed the same
+// payee under most often.
+func suggestCategories(ctx context.Context, repos repository.Repositories, userID int, candidates []*model.ImportCandidate) error {
+	engine, err := loadRules(ctx, repos.Rules, userID)
+	if err != nil {
+		return err
+	}
+	descriptions := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		descriptions[i] = candidateDescription(candidate)
+	}
+	learned, err := repos.Expenses.CategoriesByDescription(ctx, userID, descriptions)
+	if err != nil {
+		return err
+	}
+
+	for i, candidate := range candidates {
+		category := engine.Evaluate(candidateSubject(candidate)).Category
+		if category == "" {
+			category = learned[strings.ToLower(descriptions[i])]
+		}
+		if category != "" {
+			candidate.Category = &category
+		}
+	}
+	return nil
+}
+
+// candidateDescription is what the expense a candidate becomes is described
+// as: the payee, or the memo when the bank gave none.
+func candidateDescription(c *model.ImportCandidate) string {
+	description := c.Payee
+	if description == "" {
+		description = c.Memo
+	}
+	return strings.ToValidUTF8(description[:min(len(description), maxDescriptionLength)], "")
+}
+
+func candidateSubject(c *model.ImportCandidate) rules.Subject {
+	return rules.Subject{Description: candidateDescription(c), Amount: c.Amount, Date: c.PostedAt}
+}
+
+func validateReviewDecisions(decisions []ReviewDecision) error {
+	if len(decisions) == 0 {
+		return apperr.Validation("no decisions", apperr.Field("decisions", "must not be empty"))
+	}
+	if len(decisions) > maxReviewDecisions {
+		return apperr.Validation("too many decisions", apperr.Field("decisions", fmt.Sprintf("must have at most %d items", maxReviewDecisions)))
+	}
+	var fields []apperr.FieldError
+	seen := make(map[int64]bool, len(decisions))
+	for i, decision := range decisions {
+		prefix := fmt.Sprintf("decisions[%d].", i)
+		if decision.Action != ReviewAccept && decision.Action != ReviewDiscard {
+			fields = append(fields, apperr.Field(prefix+"action", "must be accept or discard"))
+		}
+		if seen[decision.CandidateID] {
+			fields = append(fields, apperr.Field(prefix+"candidate_id", "appears more than once"))
+		}
+		seen[decision.CandidateID] = true
+		fields = append(fields, validateCandidateEdit(decision.CandidateEdit, prefix)...)
+	}
+	if len(fields) > 0 {
+		return apperr.Validation("invalid decisions", fields...)
+	}
+	return nil
+}
+
+func validateCandidateEdit(edit CandidateEdit, prefix string) []apperr.FieldError {
+	var fields []apperr.FieldError
+	if edit.Amount != nil && *edit.Amount <= 0 {
+		fields = append(fields, apperr.Field(prefix+"amount", "must be greater than 0"))
+	}
+	if edit.Category != nil && strings.TrimSpace(*edit.Category) == "" {
+		fields = append(fields, apperr.Field(prefix+"category", "must not be empty"))
+	}
+	return fields
+}
+
+func applyCandidateEdit(candidate *model.ImportCandidate, edit CandidateEdit) {
+	if edit.Amount != nil {
+		candidate.Amount = *edit.Amount
+	}
+	if edit.Category != nil {
+		candidate.Category = edit.Category
+	}
+}
+
+func lockCandidate(ctx context.Context, repo repository.ImportRepository, candidateID int64, importID, userID int) (*model.ImportCandidate, error) {
+	candidate, err := repo.GetCandidateForUpdate(ctx, candidateID, importID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCandidateNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch candidate: %w", err)
+	}
+	return candidate, nil
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/rules"
+	"expense-tracker/internal/tracing"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxRulesPerUser   = 100
+	maxRuleNameLength = 100
+	maxRulePattern    = 500
+	maxRuleTags       = 20
+	maxTagLength      = 50
+	defaultApplyLimit = 200
+	maxApplyLimit     = 1000
+	// maxApplyScan bounds the expenses one apply call reads, so a call that
+	// finds few changes still finishes in time.
+	maxApplyScan  = 10000
+	applyPageSize = 500
+)
+
+var ErrRuleNotFound = apperr.NotFound("rule not found")
+
+type ApplyRulesResult struct {
+	Changes []*model.RuleChange
+	// HasMore is set when the call stopped before the last expense, at the
+	// change limit or the scan limit; calling again with NextAfterID picks
+	// up where it stopped.
+	HasMore     bool
+	NextAfterID int
+}
+
+// RuleService manages categorization rules. The rules themselves run where
+// expenses come in, through labelNewExpense: AddExpenseService, batch
+// creates, sync creates and statement imports.
+type RuleService struct {
+	ruleRepo    repository.RuleRepository
+	expenseRepo repository.ExpenseRepository
+	tx          *repository.TxManager
+}
+
+func NewRuleService(ruleRepo repository.RuleRepository, expenseRepo repository.ExpenseRepository, tx *repository.TxManager) *RuleService {
+	return &RuleService{ruleRepo: ruleRepo, expenseRepo: expenseRepo, tx: tx}
+}
+
+func (s *RuleService) ListRulesService(ctx context.Context, userID int) ([]*model.Rule, error) {
+	list, err := s.ruleRepo.ListRules(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rules: %w", err)
+	}
+	return list, nil
+}
+
+func (s *RuleService) GetRuleService(ctx context.Context, ruleID, userID int) (*model.Rule, error) {
+	rule, err := s.ruleRepo.GetRule(ctx, ruleID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (s *RuleService) CreateRuleService(ctx context.Context, userID int, rule *model.Rule) (_ *model.Rule, err error) {
+	ctx, span := tracing.Start(ctx, "RuleService.CreateRuleService")
+	defer func() { tracing.End(span, err) }()
+
+	if err := normalizeRule(rule); err != nil {
+		return nil, err
+	}
+
+	var created *model.Rule
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		// the user lock makes concurrent creates count one after another
+		if err := repos.Users.LockUser(ctx, userID); err != nil {
+			return fmt.Errorf("failed to lock user: %w", err)
+		}
+		count, err := repos.Rules.CountRules(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to count rules: %w", err)
+		}
+		if count >= maxRulesPerUser {
+			return apperr.Conflict(fmt.Sprintf("at most %d rules are allowed", maxRulesPerUser))
+		}
+		created, err = repos.Rules.CreateRule(ctx, userID, rule)
+		if err != nil {
+			return fmt.Errorf("failed to create rule: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateRuleService replaces every setting of the rule.
+func (s *RuleService) UpdateRuleService(ctx context.Context, userID int, rule *model.Rule) (_ *model.Rule, err error) {
+	ctx, span := tracing.Start(ctx, "RuleService.UpdateRuleService")
+	defer func() { tracing.End(span, err) }()
+
+	if err := normalizeRule(rule); err != nil {
+		return nil, err
+	}
+	updated, err := s.ruleRepo.UpdateRule(ctx, userID, rule)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRuleNotFound
+		}
+		return nil, fmt.Errorf("failed to update rule: %w", err)
+	}
+	return updated, nil
+}
+
+func (s *RuleService) DeleteRuleService(ctx context.Context, ruleID, userID int) (err error) {
+	ctx, span := tracing.Start(ctx, "RuleService.DeleteRuleService")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.ruleRepo.DeleteRule(ctx, ruleID, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrRuleNotFound
+		}
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+	return nil
+}
+
+// ApplyRulesService runs the rules over the user's existing expenses in id
+// order, starting after afterID. Unlike on new expenses, a matching rule's
+// category replaces the one an expense has. With dryRun nothing is written
+// and the result previews the changes. At most limit expenses change, and at
+// most maxApplyScan are looked at, per call; NextAfterID continues from where
+// a call stopped.
+func (s *RuleService) ApplyRulesService(ctx context.Context, userID int, dryRun bool, afterID, limit int) (_ *ApplyRulesResult, err error) {
+	ctx, span := tracing.Start(ctx, "RuleService.ApplyRulesService")
+	defer func() { tracing.End(span, err) }()
+
+	if limit <= 0 {
+		limit = defaultApplyLimit
+	}
+	limit = min(limit, maxApplyLimit)
+
+	// plan outside the transaction; applying checks every change again
+	engine, err := loadRules(ctx, s.ruleRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.planRuleChanges(ctx, engine, userID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || len(result.Changes) == 0 {
+		return result, nil
+	}
+
+	planned := result.Changes
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		engine, err := loadRules(ctx, repos.Rules, userID)
+		if err != nil {
+			return err
+		}
+		result.Changes = result.Changes[:0:0]
+		for _, change := range planned {
+			// lock, then plan again in case the expense or the rules changed
+			before, err := lockExpense(ctx, repos.Expenses, change.ExpenseID, userID)
+			if errors.Is(err, ErrExpenseNotFound) {
+				continue // deleted since it was planned
+			}
+			if err != nil {
+				return err
+			}
+			labels := expenseLabels(before)
+			outcome := engine.Evaluate(ruleSubject(before))
+			if !outcome.Apply(&labels, true) {
+				continue
+			}
+			applied := &model.RuleChange{ExpenseID: before.ID, Before: expenseLabels(before), After: labels, RuleIDs: outcome.RuleIDs}
+
+			after, err := repos.Expenses.UpdateExpenseLabels(ctx, before.ID, userID, labels)
+			if err != nil {
+				return err
+			}
+			if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionUpdate, model.AuditEntityExpense, before.ID, before, after); err != nil {
+				return err
+			}
+			if err := recordExpenseChange(ctx, repos, userID, model.EventExpenseUpdated, before, after); err != nil {
+				return err
+			}
+			result.Changes = append(result.Changes, applied)
+		}
+		return nil
+	})
+	if err != nil {
+		if apperr.KindOf(err) != apperr.KindInternal {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to apply rules: %w", err)
+	}
+	return result, nil
+}
+
+// planRuleChanges pages through the user's expenses after afterID and lists
+// up to limit the rules would change.
+func (s *RuleService) planRuleChanges(ctx context.Context, engine *rules.Engine, userID, afterID, limit int) (*ApplyRulesResult, error) {
+	result := &ApplyRulesResult{Changes: []*model.RuleChange{}}
+	for scanned := 0; scanned < maxApplyScan; {
+		page, err := s.expenseRepo.ListExpensesAfterID(ctx, userID, afterID, min(applyPageSize, maxApplyScan-scanned))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch expenses: %w", err)
+		}
+		for _, expense := range page {
+			labels := expenseLabels(expense)
+			outcome := engine.Evaluate(ruleSubject(expense))
+			if outcome.Apply(&labels, true) {
+				if len(result.Changes) == limit {
+					result.HasMore, result.NextAfterID = true, afterID
+					return result, nil
+				}
+				result.Changes = append(result.Changes, &model.RuleChange{
+					ExpenseID: expense.ID,
+					Before:    expenseLabels(expense),
+					After:     labels,
+					RuleIDs:   outcome.RuleIDs,
+				})
+			}
+			afterID = expense.ID
+		}
+		scanned += len(page)
+		if len(page) < applyPageSize {
+			return result, nil
+		}
+	}
+	// scanned as much as one call may; there may be more
+	result.HasMore, result.NextAfterID = true, afterID
+	return result, nil
+}
+
+// loadRules compiles the user's enabled rules.
+func loadRules(ctx context.Context, repo repository.RuleRepository, userID int) (*rules.Engine, error) {
+	list, err := repo.ListRules(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rules: %w", err)
+	}
+	engine, err := rules.Compile(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile rules: %w", err)
+	}
+	return engine, nil
+}
+
+// labelNewExpense runs the user's rules on an expense about to be created.
+// They add tags and the reimbursable flag, and pick the category when e has
+// none; an explicit category always wins. It fails when e is still left
+// without a category.
+func labelNewExpense(engine *rules.Engine, e *model.Expense) error {
+	subject := ruleSubject(e)
+	if subject.Date.IsZero() {
+		// a zero CreatedAt is stored as now
+		subject.Date = time.Now()
+	}
+	labels := expenseLabels(e)
+	engine.Evaluate(subject).Apply(&labels, false)
+	if labels.Category == "" {
+		return apperr.Validation("category is required", apperr.Field("category", "is required when no rule sets one"))
+	}
+	e.Category, e.Tags, e.Reimbursable = labels.Category, labels.Tags, labels.Reimbursable
+	return nil
+}
+
+func ruleSubject(e *model.Expense) rules.Subject {
+	return rules.Subject{Description: e.Description, Amount: e.Amount, Date: e.CreatedAt}
+}
+
+func expenseLabels(e *model.Expense) model.ExpenseLabels {
+	return model.ExpenseLabels{Category: e.Category, Tags: slices.Clone(e.Tags), Reimbursable: e.Reimbursable}
+}
+
+// normalizeRule validates rule and trims and lowercases what compares
+// case-insensitively, so it is stored the way it is matched.
+func normalizeRule(rule *model.Rule) error {
+	var fields []apperr.FieldError
+	field := func(name, message string) {
+		fields = append(fields, apperr.Field(name, message))
+	}
+
+	rule.Name = strings.TrimSpace(rule.Name)
+	switch {
+	case rule.Name == "":
+		field("name", "is required")
+	case len(rule.Name) > maxRuleNameLength:
+		field("name", fmt.Sprintf("must be at most %d characters long", maxRuleNameLength))
+	}
+
+	conditions := 0
+	if rule.DescriptionContains != nil {
+		contains := strings.TrimSpace(*rule.DescriptionContains)
+		rule.DescriptionContains = &contains
+		switch {
+		case contains == "":
+			field("description_contains", "must not be empty")
+		case len(contains) > maxRulePattern:
+			field("description_contains", fmt.Sprintf("must be at most %d characters long", maxRulePattern))
+		}
+		conditions++
+	}
+	if rule.DescriptionRegex != nil {
+		if len(*rule.DescriptionRegex) > maxRulePattern {
+			field("description_regex", fmt.Sprintf("must be at most %d characters long", maxRulePattern))
+		} else if _, err := regexp.Compile(*rule.DescriptionRegex); err != nil {
+			field("description_regex", "must be a valid regular expression: "+err.Error())
+		}
+		conditions++
+	}
+	if rule.MinAmount != nil {
+		if *rule.MinAmount < 0 {
+			field("min_amount", "must not be negative")
+		}
+		conditions++
+	}
+	if rule.MaxAmount != nil {
+		if rule.MinAmount != nil && *rule.MaxAmount < *rule.MinAmount {
+			field("max_amount", "must not be below min_amount")
+		}
+		conditions++
+	}
+	weekdays := make([]string, 0, len(rule.Weekdays))
+	for _, day := range rule.Weekdays {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if _, ok := rules.Weekdays[day]; !ok {
+			field("weekdays", fmt.Sprintf("%q is not a day of the week", day))
+		} else if !slices.Contains(weekdays, day) {
+			weekdays = append(weekdays, day)
+		}
+	}
+	rule.Weekdays = weekdays
+	if len(weekdays) > 0 {
+		conditions++
+	}
+	if conditions == 0 {
+		field("description_contains", "a rule needs at least one condition")
+	}
+
+	if rule.SetCategory != nil && strings.TrimSpace(*rule.SetCategory) == "" {
+		field("set_category", "must not be empty")
+	}
+	tags, tagFields := normalizeTags(rule.AddTags, "add_tags")
+	rule.AddTags = tags
+	fields = append(fields, tagFields...)
+	if rule.SetCategory == nil && len(rule.AddTags) == 0 && !rule.MarkReimbursable {
+		field("set_category", "a rule needs at least one action")
+	}
+
+	if len(fields) > 0 {
+		return apperr.Validation("invalid rule", fields...)
+	}
+	return nil
+}
+
+// normalizeTags trims, lowercases and deduplicates tags.
+func normalizeTags(tags []string, name string) ([]string, []apperr.FieldError) {
+	var fields []apperr.FieldError
+	if len(tags) > maxRuleTags {
+		fields = append(fields, apperr.Field(name, fmt.Sprintf("must have at most %d items", maxRuleTags)))
+	}
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case tag == "":
+			fields = append(fields, apperr.Field(name, "tags must not be empty"))
+		case len(tag) > maxTagLength:
+			fields = append(fields, apperr.Field(name, fmt.Sprintf("tags must be at most %d characters long", maxTagLength)))
+		default:
+			normalized = rules.MergeTags(normalized, []string{tag})
+		}
+	}
+	return normalized, fields
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/metrics"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/rules"
+	"expense-tracker/internal/tracing"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultSyncLimit = 500
+	maxSyncLimit     = 1000
+	maxSyncChanges   = 500
+	// maxClockSkew is how far ahead of the server a client's clock may be
+	// when it dates an expense it created offline.
+	maxClockSkew = 5 * time.Minute
+)
+
+// Operations a syncing client can send.
+const (
+	SyncOpCreate = "create"
+	SyncOpUpdate = "update"
+	SyncOpDelete = "delete"
+)
+
+// SyncChange is one change a client made while offline. Updates and deletes
+// carry the version the client last saw in BaseVersion; an update only sets
+// the fields that are non-nil.
+type SyncChange struct {
+	Op           string
+	UUID         string
+	BaseVersion  *int
+	Amount       *float64
+	Category     *string
+	Description  *string
+	Tags         *[]string
+	Reimbursable *bool
+	CreatedAt    *time.Time
+}
+
+// update is the UpdateExpenseInput of an update.
+func (c SyncChange) update() UpdateExpenseInput {
+	return UpdateExpenseInput{
+		Amount:       c.Amount,
+		Category:     c.Category,
+		Description:  c.Description,
+		Tags:         c.Tags,
+		Reimbursable: c.Reimbursable,
+	}
+}
+
+type SyncInput struct {
+	// Token is the sync_token of the previous sync, empty on the first one.
+	Token   string
+	Changes []SyncChange
+	Limit   int
+}
+
+type SyncResult struct {
+	Applied   []model.SyncApplied
+	Conflicts []model.SyncConflict
+	// Changes are the server's changes after the input token, the client's
+	// own applied changes included.
+	Changes []*model.SyncExpense
+	Token   string
+	HasMore bool
+}
+
+// SyncService reconciles offline clients.
+//
+// Conflict policy: a change applies only if the expense is still at the
+// client's base version; otherwise nothing of it is applied and it is
+// reported back with the server's copy, for the client to rebase and send
+// again. Exceptions that make retries safe: a create whose uuid already
+// holds the same amount and category, and a delete of an expense that is
+// already deleted, are acknowledged as applied. Creates run the user's
+// rules like AddExpenseService, so they may leave the category to a rule.
+type SyncService struct {
+	syncRepo repository.SyncRepository
+	tx       *repository.TxManager
+}
+
+func NewSyncService(syncRepo repository.SyncRepository, tx *repository.TxManager) *SyncService {
+	return &SyncService{syncRepo: syncRepo, tx: tx}
+}
+
+// SyncExpensesService applies the client's changes in one transaction and
+// returns the server's changes since the client's token.
+func (s *SyncService) SyncExpensesService(ctx context.Context, userID int, input SyncInput) (_ *SyncResult, err error) {
+	ctx, span := tracing.Start(ctx, "SyncService.SyncExpensesService")
+	defer func() { tracing.End(span, err) }()
+
+	afterSeq, err := decodeSyncToken(input.Token)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSyncChanges(input.Changes); err != nil {
+		return nil, err
+	}
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultSyncLimit
+	}
+	limit = min(limit, maxSyncLimit)
+
+	result := &SyncResult{Applied: []model.SyncApplied{}, Conflicts: []model.SyncConflict{}}
+	if len(input.Changes) > 0 {
+		creates := slices.ContainsFunc(input.Changes, func(change SyncChange) bool { return change.Op == SyncOpCreate })
+		var created int
+		err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+			// start over if the transaction is retried
+			result.Applied, result.Conflicts, created = result.Applied[:0], result.Conflicts[:0], 0
+			var engine *rules.Engine
+			if creates {
+				var err error
+				if engine, err = loadRules(ctx, repos.Rules, userID); err != nil {
+					return err
+				}
+			}
+			for i, change := range input.Changes {
+				isCreate, err := applySyncChange(ctx, repos, engine, userID, i, change, result)
+				if err != nil {
+					return err
+				}
+				if isCreate {
+					created++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			if apperr.KindOf(err) != apperr.KindInternal {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to apply sync changes: %w", err)
+		}
+		metrics.ExpensesCreated.Add(float64(created))
+	}
+
+	changes, err := s.syncRepo.ListChanges(ctx, userID, afterSeq, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch changes: %w", err)
+	}
+	if len(changes) > limit {
+		changes, result.HasMore = changes[:limit], true
+	}
+	if len(changes) > 0 {
+		afterSeq = changes[len(changes)-1].ChangeSeq
+	}
+	result.Changes = changes
+	result.Token = encodeSyncToken(afterSeq)
+	return result, nil
+}
+
+// applySyncChange applies the index-th change, or records why it could not,
+// and reports whether it created an expense. engine holds the user's rules
+// when the sync creates expenses.
+func applySyncChange(ctx context.Context, repos repository.Repositories, engine *rules.Engine, userID, index int, change SyncChange, result *SyncResult) (bool, error) {
+	existing, err := repos.Sync.GetExpenseByUUIDForUpdate(ctx, userID, change.UUID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		existing, err = nil, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	conflict := func(reason string) {
+		result.Conflicts = append(result.Conflicts, model.SyncConflict{UUID: change.UUID, Reason: reason, Server: existing})
+	}
+
+	switch change.Op {
+	case SyncOpCreate:
+		if existing != nil {
+			// a retry matches on what it sent; a category left to the rules is not compared
+			sameCategory := change.Category == nil || existing.Category == *change.Category
+			if !existing.Deleted && existing.Amount == roundCents(*change.Amount) && sameCategory {
+				result.Applied = append(result.Applied, model.SyncApplied{UUID: change.UUID, ID: existing.ID, Version: existing.Version})
+			} else {
+				conflict(model.SyncConflictExists)
+			}
+			return false, nil
+		}
+		expense := &model.Expense{Amount: *change.Amount}
+		change.update().applyTo(expense)
+		if change.CreatedAt != nil {
+			expense.CreatedAt = *change.CreatedAt
+		}
+		if err := labelNewExpense(engine, expense); err != nil {
+			if apperr.KindOf(err) == apperr.KindValidation {
+				return false, apperr.Validation("invalid sync changes",
+					apperr.Field(fmt.Sprintf("changes[%d].category", index), "is required when no rule sets one"))
+			}
+			return false, err
+		}
+		expense, err := repos.Sync.CreateExpenseWithUUID(ctx, userID, change.UUID, expense)
+		if err != nil {
+			return false, err
+		}
+		if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionCreate, model.AuditEntityExpense, expense.ID, nil, expense); err != nil {
+			return false, err
+		}
+		if err := recordExpenseChange(ctx, repos, userID, model.EventExpenseCreated, nil, expense); err != nil {
+			return false, err
+		}
+		result.Applied = append(result.Applied, model.SyncApplied{UUID: change.UUID, ID: expense.ID, Version: expense.Version})
+		return true, nil
+
+	case SyncOpUpdate:
+		switch {
+		case existing == nil:
+			conflict(model.SyncConflictNotFound)
+			return false, nil
+		case existing.Deleted:
+			conflict(model.SyncConflictDeleted)
+			return false, nil
+		case existing.Version != *change.BaseVersion:
+			conflict(model.SyncConflictVersion)
+			return false, nil
+		}
+		// the row is locked already; this reads the fields sync does not carry
+		before, err := repos.Expenses.GetExpenseByID(ctx, existing.ID, userID)
+		if err != nil {
+			return false, err
+		}
+		after := *before
+		change.update().applyTo(&after)
+		updated, err := repos.Expenses.UpdateExpense(ctx, existing.ID, userID, &after)
+		if err != nil {
+			return false, err
+		}
+		if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionUpdate, model.AuditEntityExpense, existing.ID, before, updated); err != nil {
+			return false, err
+		}
+		if err := recordExpenseChange(ctx, repos, userID, model.EventExpenseUpdated, before, updated); err != nil {
+			return false, err
+		}
+		result.Applied = append(result.Applied, model.SyncApplied{UUID: change.UUID, ID: updated.ID, Version: updated.Version})
+		return false, nil
+
+	default: // SyncOpDelete
+		if existing == nil || existing.Deleted {
+			// deleting what is already gone is what the client wanted
+			result.Applied = append(result.Applied, model.SyncApplied{UUID: change.UUID})
+			return false, nil
+		}
+		if existing.Version != *change.BaseVersion {
+			// the client has not seen the latest edit; don't throw it away
+			conflict(model.SyncConflictVersion)
+			return false, nil
+		}
+		before, err := repos.Expenses.GetExpenseByID(ctx, existing.ID, userID)
+		if err != nil {
+			return false, err
+		}
+		if err := repos.Expenses.DeleteExpense(ctx, existing.ID, userID); err != nil {
+			return false, err
+		}
+		if err := auditChange(ctx, repos.Audit, &userID, userID, model.AuditActionDelete, model.AuditEntityExpense, existing.ID, before, nil); err != nil {
+			return false, err
+		}
+		if err := recordExpenseChange(ctx, repos, userID, model.EventExpenseDeleted, before, nil); err != nil {
+			return false, err
+		}
+		result.Applied = append(result.Applied, model.SyncApplied{UUID: change.UUID, ID: existing.ID, Version: existing.Version + 1})
+		return false, nil
+	}
+}
+
+// validateSyncChanges checks every change up front and canonicalizes uuids,
+// so that a bad change rejects the whole request before anything is applied.
+func validateSyncChanges(changes []SyncChange) error {
+	if len(changes) > maxSyncChanges {
+		return apperr.Validation("too many changes", apperr.Field("changes", fmt.Sprintf("must have at most %d items", maxSyncChanges)))
+	}
+	var fields []apperr.FieldError
+	for i := range changes {
+		change := &changes[i]
+		field := func(name, message string) {
+			fields = append(fields, apperr.Field(fmt.Sprintf("changes[%d].%s", i, name), message))
+		}
+
+		id, err := uuid.Parse(change.UUID)
+		if err != nil {
+			field("uuid", "must be a UUID")
+		} else {
+			change.UUID = id.String()
+		}
+		if change.Amount != nil && *change.Amount <= 0 {
+			field("amount", "must be greater than 0")
+		}
+		if change.Category != nil && strings.TrimSpace(*change.Category) == "" {
+			field("category", "must not be empty")
+		}
+		if change.Description != nil {
+			description := strings.TrimSpace(*change.Description)
+			change.Description = &description
+			if len(description) > maxDescriptionLength {
+				field("description", fmt.Sprintf("must be at most %d characters long", maxDescriptionLength))
+			}
+		}
+		if change.Tags != nil {
+			tags, tagFields := normalizeTags(*change.Tags, fmt.Sprintf("changes[%d].tags", i))
+			change.Tags = &tags
+			fields = append(fields, tagFields...)
+		}
+
+		switch change.Op {
+		case SyncOpCreate:
+			if change.Amount == nil {
+				field("amount", "is required")
+			}
+			if change.CreatedAt != nil {
+				// created_at is stored without a time zone, as UTC
+				createdAt := change.CreatedAt.UTC()
+				change.CreatedAt = &createdAt
+				if createdAt.After(time.Now().Add(maxClockSkew)) {
+					field("created_at", "must not be in the future")
+				}
+			}
+		case SyncOpUpdate:
+			if change.BaseVersion == nil {
+				field("base_version", "is required")
+			}
+			if change.update().isEmpty() {
+				field("amount", "an update must set at least one field")
+			}
+		case SyncOpDelete:
+			if change.BaseVersion == nil {
+				field("base_version", "is required")
+			}
+		default:
+			field("op", "must be one of: create, update, delete")
+		}
+	}
+	if len(fields) > 0 {
+		return apperr.Validation("invalid sync changes", fields...)
+	}
+	return nil
+}
+
+// roundCents matches the precision amounts are stored with.
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+const syncTokenPrefix = "seq:"
+
+// encodeSyncToken hides the change sequence behind an opaque token, so its
+// format can change without breaking clients.
+func encodeSyncToken(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(seq, 10)))
+}
+
+func decodeSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	invalid := apperr.Validation("invalid sync token", apperr.Field("sync_token", "is not a token this server issued"))
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, invalid
+	}
+	digits, ok := strings.CutPrefix(string(raw), syncTokenPrefix)
+	if !ok {
+		return 0, invalid
+	}
+	seq, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, invalid
+	}
+	return seq, nil
+}
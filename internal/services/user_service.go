@@ -3,8 +3,11 @@ package services
 import (
 	"context"
 	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/metrics"
 	"expense-tracker/internal/model"
 	"expense-tracker/internal/repository"
+	"expense-tracker/internal/tracing"
 	"expense-tracker/internal/utils"
 	"regexp"
 
@@ -13,13 +16,23 @@ import (
 
 type UserService struct {
 	userRepo repository.UserRepository
+	tx       *repository.TxManager
 }
 
-func NewUserService(userRepo repository.UserRepository) *UserService {
-	return &UserService{userRepo: userRepo}
+// ErrInvalidCredentials does not say whether the email or the password was
+// wrong, so login can't be used to find registered emails.
+var ErrInvalidCredentials = apperr.Unauthorized("invalid email or password")
+
+var ErrUserNotFound = apperr.NotFound("user not found")
+
+func NewUserService(userRepo repository.UserRepository, tx *repository.TxManager) *UserService {
+	return &UserService{userRepo: userRepo, tx: tx}
 }
 
-func (s *UserService) RegisterUser(ctx context.Context, email, password string) (*model.User, error) {
+func (s *UserService) RegisterUser(ctx context.Context, email, password string) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.RegisterUser")
+	defer func() { tracing.End(span, err) }()
+
 	if err := s.validateEmail(email); err != nil {
 		return nil, err
 	}
@@ -28,47 +41,63 @@ func (s *UserService) RegisterUser(ctx context.Context, email, password string)
 		return nil, err
 	}
 
+	_, hashSpan := tracing.Start(ctx, "bcrypt.hash")
 	hashedPassword, err := utils.HashPassword(password)
+	hashSpan.End()
 	if err != nil {
 		return nil, err
 	}
 
 	// call repo
 
-	user, err := s.userRepo.CreateUser(ctx, email, hashedPassword)
+	var user *model.User
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		var err error
+		user, err = repos.Users.CreateUser(ctx, email, hashedPassword)
+		if err != nil {
+			return err
+		}
+		// a new user is their own actor
+		id := int(user.ID)
+		return auditChange(ctx, repos.Audit, &id, id, model.AuditActionCreate, model.AuditEntityUser, id, nil, user)
+	})
 
 	if err != nil {
 		return nil, err
 	}
+	metrics.UsersRegistered.Inc()
 	return user, nil
 }
 
 func (s *UserService) validateEmail(email string) error {
 	if email == "" {
-		return errors.New("email is required")
+		return apperr.Validation("email is required", apperr.Field("email", "is required"))
 	}
 
 	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	if !emailRegex.MatchString(email) {
-		return errors.New("invalid email format")
+		return apperr.Validation("invalid email format", apperr.Field("email", "must be a valid email address"))
 	}
 	return nil
 }
 
 func (s *UserService) validatePassword(password string) error {
 	if password == "" {
-		return errors.New("password is required")
+		return apperr.Validation("password is required", apperr.Field("password", "is required"))
 	}
 
 	if len(password) < 6 {
-		return errors.New("password must be at least 6 characters")
+		return apperr.Validation("password must be at least 6 characters", apperr.Field("password", "must be at least 6 characters long"))
 	}
 	return nil
 }
 
-func (s *UserService) LogInUserService(ctx context.Context, email, password string) (*model.User, error) {
+func (s *UserService) LogInUserService(ctx context.Context, email, password string) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.LogInUserService")
+	defer func() { tracing.End(span, err) }()
 
 	if err := s.validateLoginEmail(email); err != nil {
+		metrics.LoginsFailed.WithLabelValues("invalid_input").Inc()
 		return nil, err
 	}
 
@@ -77,33 +106,41 @@ func (s *UserService) LogInUserService(ctx context.Context, email, password stri
 	user, err := s.userRepo.LogInUser(ctx, email)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errors.New("no user found")
+			metrics.LoginsFailed.WithLabelValues("unknown_email").Inc()
+			return nil, ErrInvalidCredentials
 		}
 		return nil, err
 	}
 	// compare password
 
-	if err := utils.CheckPassword(password, user.PasswordHash); err != nil {
-		return nil, errors.New("incorrect password")
+	_, compareSpan := tracing.Start(ctx, "bcrypt.compare")
+	err = utils.CheckPassword(password, user.PasswordHash)
+	compareSpan.End()
+	if err != nil {
+		metrics.LoginsFailed.WithLabelValues("wrong_password").Inc()
+		return nil, ErrInvalidCredentials
 	}
 	return user, nil
 }
 
 func (s *UserService) validateLoginEmail(email string) error {
 	if email == "" {
-		return errors.New("invalid email id")
+		return apperr.Validation("email is required", apperr.Field("email", "is required"))
 	}
 	return nil
 }
 
-func (s *UserService) GetUserService(ctx context.Context, id int) (*model.User, error) {
+func (s *UserService) GetUserService(ctx context.Context, id int) (_ *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserService")
+	defer func() { tracing.End(span, err) }()
+
 	if id <= 0 {
-		return nil, errors.New("invalid user id")
+		return nil, apperr.Validation("invalid user id")
 	}
 	user, err := s.userRepo.GetUser(ctx, id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errors.New("invalid user")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/repository"
+	"expense-tracker/internal/tracing"
+	"expense-tracker/internal/webhook"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxWebhooksPerUser    = 10
+	defaultDeliveryLimit  = 50
+	maxDeliveryLimit      = 200
+	maxDeliveryErrorBytes = 500
+)
+
+// WebhookOptions controls endpoint validation and the retry schedule.
+type WebhookOptions struct {
+	// AllowInsecureTargets permits plain http and private addresses, for
+	// local development only.
+	AllowInsecureTargets bool
+	MaxAttempts          int
+	RetryBase            time.Duration
+	RetryMax             time.Duration
+}
+
+type WebhookService struct {
+	webhookRepo repository.WebhookRepository
+	outboxRepo  repository.OutboxRepository
+	tx          *repository.TxManager
+	opts        WebhookOptions
+}
+
+func NewWebhookService(webhookRepo repository.WebhookRepository, outboxRepo repository.OutboxRepository, tx *repository.TxManager, opts WebhookOptions) *WebhookService {
+	return &WebhookService{webhookRepo: webhookRepo, outboxRepo: outboxRepo, tx: tx, opts: opts}
+}
+
+var (
+	ErrWebhookNotFound  = apperr.NotFound("webhook not found")
+	ErrDeliveryNotFound = apperr.NotFound("no dead delivery with this id")
+)
+
+// CreateWebhookService registers an endpoint for the given event types. The
+// returned endpoint carries the signing secret, which is not shown again.
+func (s *WebhookService) CreateWebhookService(ctx context.Context, userID int, url string, events []string) (_ *model.WebhookEndpoint, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.CreateWebhookService")
+	defer func() { tracing.End(span, err) }()
+
+	if msg, ok := webhook.ValidateURL(url, s.opts.AllowInsecureTargets); !ok {
+		return nil, apperr.Validation("invalid webhook url", apperr.Field("url", msg))
+	}
+	if len(events) == 0 {
+		return nil, apperr.Validation("at least one event is required", apperr.Field("events", "must not be empty"))
+	}
+	for _, event := range events {
+		if !slices.Contains(model.EventTypes, event) {
+			return nil, apperr.Validation("unknown event type", apperr.Field("events", fmt.Sprintf("unknown event type %q", event)))
+		}
+	}
+	events = slices.Compact(slices.Sorted(slices.Values(events)))
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	var endpoint *model.WebhookEndpoint
+	err = s.tx.WithinTx(ctx, func(repos repository.Repositories) error {
+		// the user lock makes concurrent creates count one after another
+		if err := repos.Users.LockUser(ctx, userID); err != nil {
+			return fmt.Errorf("failed to lock user: %w", err)
+		}
+		existing, err := repos.Webhooks.ListEndpoints(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch webhooks: %w", err)
+		}
+		if len(existing) >= maxWebhooksPerUser {
+			return apperr.Conflict(fmt.Sprintf("at most %d webhooks per user", maxWebhooksPerUser))
+		}
+		endpoint, err = repos.Webhooks.CreateEndpoint(ctx, userID, url, secret, events)
+		if err != nil {
+			return fmt.Errorf("failed to create webhook: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+func (s *WebhookService) ListWebhooksService(ctx context.Context, userID int) ([]*model.WebhookEndpoint, error) {
+	endpoints, err := s.webhookRepo.ListEndpoints(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhooks: %w", err)
+	}
+	return endpoints, nil
+}
+
+func (s *WebhookService) GetWebhookService(ctx context.Context, endpointID, userID int) (*model.WebhookEndpoint, error) {
+	endpoint, err := s.webhookRepo.GetEndpoint(ctx, endpointID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch webhook: %w", err)
+	}
+	return endpoint, nil
+}
+
+// DeleteWebhookService removes the endpoint together with its deliveries.
+func (s *WebhookService) DeleteWebhookService(ctx context.Context, endpointID, userID int) (err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.DeleteWebhookService")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.webhookRepo.DeleteEndpoint(ctx, endpointID, userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrWebhookNotFound
+		}
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveriesService pages through an endpoint's deliveries, newest first,
+// optionally only those with the given status.
+func (s *WebhookService) ListDeliveriesService(ctx context.Context, endpointID, userID int, status string, beforeID int64, limit int) ([]*model.WebhookDelivery, error) {
+	switch status {
+	case "", model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead:
+	default:
+		return nil, apperr.Validation("invalid status", apperr.Field("status", "must be pending, delivered or dead"))
+	}
+	if beforeID < 0 {
+		return nil, apperr.Validation("invalid before", apperr.Field("before", "must not be negative"))
+	}
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	limit = min(limit, maxDeliveryLimit)
+
+	// ownership check; deliveries themselves are keyed by endpoint only
+	if _, err := s.GetWebhookService(ctx, endpointID, userID); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.webhookRepo.ListDeliveries(ctx, endpointID, status, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RetryDeliveryService puts a dead delivery back in the queue with a fresh
+// set of attempts.
+func (s *WebhookService) RetryDeliveryService(ctx context.Context, endpointID, userID int, deliveryID int64) (_ *model.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.RetryDeliveryService")
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.GetWebhookService(ctx, endpointID, userID); err != nil {
+		return nil, err
+	}
+	delivery, err := s.webhookRepo.RetryDelivery(ctx, deliveryID, endpointID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to retry delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// DispatchEventsService fans up to limit outbox events out to the deliveries
+// of every subscribed endpoint.
+func (s *WebhookService) DispatchEventsService(ctx context.Context, limit int) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.DispatchEventsService")
+	defer func() { tracing.End(span, err) }()
+
+	dispatched, err := s.outboxRepo.DispatchEvents(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to dispatch events: %w", err)
+	}
+	return dispatched, nil
+}
+
+// ClaimDeliveriesService leases up to limit due deliveries. A delivery whose
+// result is never recorded, say because the process died, comes due again
+// once the lease runs out.
+func (s *WebhookService) ClaimDeliveriesService(ctx context.Context, limit int, lease time.Duration) ([]*model.PendingDelivery, error) {
+	deliveries, err := s.webhookRepo.ClaimDeliveries(ctx, limit, lease)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RecordDeliveryService stores the outcome of one attempt and returns the
+// resulting state: delivered, retry or dead.
+func (s *WebhookService) RecordDeliveryService(ctx context.Context, d *model.PendingDelivery, statusCode int, sendErr error) (string, error) {
+	if sendErr == nil {
+		if err := s.webhookRepo.MarkDelivered(ctx, d.ID, statusCode); err != nil {
+			return "", fmt.Errorf("failed to mark delivery delivered: %w", err)
+		}
+		return model.DeliveryDelivered, nil
+	}
+
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+	// the error quotes the endpoint's status line, which may be any bytes;
+	// Postgres text takes neither invalid UTF-8 nor NUL, and a failed write
+	// here would leave the delivery to be reclaimed forever
+	lastError := strings.ReplaceAll(strings.ToValidUTF8(sendErr.Error(), "\uFFFD"), "\x00", "")
+	// cutting may split a rune; dropping the partial one keeps it valid
+	lastError = strings.ToValidUTF8(lastError[:min(len(lastError), maxDeliveryErrorBytes)], "")
+
+	result := model.DeliveryDead
+	var next *time.Time
+	if d.Attempts < s.opts.MaxAttempts {
+		at := time.Now().Add(s.retryDelay(d.Attempts))
+		next, result = &at, "retry"
+	}
+	if err := s.webhookRepo.MarkFailed(ctx, d.ID, code, lastError, next); err != nil {
+		return "", fmt.Errorf("failed to mark delivery failed: %w", err)
+	}
+	return result, nil
+}
+
+// retryDelay doubles RetryBase per attempt up to RetryMax, then takes up to a
+// fifth off so endpoints that failed together are not retried together.
+func (s *WebhookService) retryDelay(attempts int) time.Duration {
+	delay := s.opts.RetryBase
+	for i := 1; i < attempts && delay < s.opts.RetryMax; i++ {
+		delay *= 2
+	}
+	delay = min(delay, s.opts.RetryMax)
+	return delay - rand.N(delay/5+1)
+}
+
+// PurgeEventsService deletes outbox events dispatched more than retention
+// ago, along with their deliveries. With undispatched, which is for when no
+// dispatcher runs, events created more than retention ago are deleted too.
+func (s *WebhookService) PurgeEventsService(ctx context.Context, retention time.Duration, undispatched bool) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.PurgeEventsService")
+	defer func() { tracing.End(span, err) }()
+
+	purged, err := s.outboxRepo.DeleteExpiredEvents(ctx, time.Now().Add(-retention), undispatched)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox events: %w", err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,47 @@
+package statement
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCAMT053SplitBatch(t *testing.T) {
+	statements, err := Parse(readFixture(t, "split.camt053.xml"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(statements) != 1 {
+		t.Fatalf("got %d statements, want 1", len(statements))
+	}
+	s := statements[0]
+	if s.Format != FormatCAMT053 || s.Account != "DE89370400440532013000" || s.Currency != "EUR" {
+		t.Errorf("statement = %s %q %q", s.Format, s.Account, s.Currency)
+	}
+
+	booked := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	want := []Transaction{
+		// the batch entry is split by its details, which carry the amounts
+		{FITID: "BATCH-77/1", PostedAt: booked, Amount: -100, Currency: "EUR", Payee: "Landlord GmbH", Memo: "Rent October"},
+		// a detail without its own indicator takes the entry's
+		{FITID: "E2E-9", PostedAt: booked, Amount: -50, Currency: "EUR", Payee: "Power Co"},
+	}
+	if len(s.Transactions) != 3 {
+		t.Fatalf("got %d transactions, want 3 (the pending entry skipped)", len(s.Transactions))
+	}
+	for i := range want {
+		if s.Transactions[i] != want[i] {
+			t.Errorf("transaction %d = %+v, want %+v", i, s.Transactions[i], want[i])
+		}
+	}
+
+	fee := s.Transactions[2]
+	if fee.Amount != -12.345 || fee.Memo != "Card fee" {
+		t.Errorf("fee = %+v, want -12.345 with the entry's info as memo", fee)
+	}
+	if !fee.PostedAt.Equal(time.Date(2026, 10, 16, 7, 30, 0, 0, time.UTC)) {
+		t.Errorf("fee posted at %v, want 07:30 UTC", fee.PostedAt)
+	}
+	if fee.FITID == "" {
+		t.Error("fee has no id")
+	}
+}
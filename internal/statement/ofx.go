@@ -0,0 +1,118 @@
+package statement
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ofxToken matches an element and the text up to the next one. OFX 1.x is
+// SGML where leaf elements are not closed, so the parser works on this flat
+// token stream rather than a tree, which reads OFX 2.x XML just as well.
+var ofxToken = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<]*)`)
+
+func parseOFX(data []byte) ([]*Statement, error) {
+	var (
+		statements []*Statement
+		current    *Statement
+		txn        *Transaction
+	)
+	for _, m := range ofxToken.FindAllSubmatch(data, -1) {
+		closing := len(m[1]) > 0
+		tag := strings.ToUpper(string(m[2]))
+		value := strings.TrimSpace(html.UnescapeString(string(m[3])))
+
+		switch {
+		case tag == "STMTRS" || tag == "CCSTMTRS":
+			if closing {
+				current = nil
+			} else {
+				current = &Statement{Format: FormatOFX}
+				statements = append(statements, current)
+			}
+		case tag == "STMTTRN":
+			if closing {
+				if txn != nil && current != nil {
+					current.Transactions = append(current.Transactions, *txn)
+				}
+				txn = nil
+			} else {
+				txn = &Transaction{}
+			}
+		case closing || current == nil:
+		case txn != nil:
+			if err := setOFXField(txn, tag, value); err != nil {
+				return nil, err
+			}
+		case tag == "ACCTID":
+			current.Account = value
+		case tag == "CURDEF":
+			current.Currency = value
+		}
+	}
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("ofx: no bank or credit card statement found")
+	}
+	for _, s := range statements {
+		for i := range s.Transactions {
+			if s.Transactions[i].Currency == "" {
+				s.Transactions[i].Currency = s.Currency
+			}
+		}
+		assignMissingIDs(s.Account, s.Transactions)
+	}
+	return statements, nil
+}
+
+func setOFXField(txn *Transaction, tag, value string) error {
+	var err error
+	switch tag {
+	case "FITID":
+		txn.FITID = value
+	case "DTPOSTED":
+		txn.PostedAt, err = parseOFXDate(value)
+	case "TRNAMT":
+		txn.Amount, err = parseAmount(value)
+	case "NAME", "PAYEE":
+		if txn.Payee == "" {
+			txn.Payee = value
+		}
+	case "MEMO":
+		txn.Memo = value
+	case "CURSYM":
+		txn.Currency = value
+	}
+	if err != nil {
+		return fmt.Errorf("ofx: %s: %w", tag, err)
+	}
+	return nil
+}
+
+// parseOFXDate reads YYYYMMDD[HHMMSS[.XXX]][[offset[:TZ]]], e.g.
+// "20261017120000.000[-5:EST]". Without an offset the time is taken as UTC.
+func parseOFXDate(s string) (time.Time, error) {
+	loc := time.UTC
+	if open := strings.IndexByte(s, '['); open >= 0 {
+		offset, _, _ := strings.Cut(strings.TrimSuffix(s[open+1:], "]"), ":")
+		hours, err := strconv.ParseFloat(offset, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time zone in %q", s)
+		}
+		loc = time.FixedZone("", int(hours*3600))
+		s = s[:open]
+	}
+	s, _, _ = strings.Cut(s, ".")
+	for _, layout := range []string{"20060102150405", "200601021504", "20060102"} {
+		if len(s) == len(layout) {
+			t, err := time.ParseInLocation(layout, s, loc)
+			if err != nil {
+				break
+			}
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
@@ -0,0 +1,124 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOFXDate(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "20261017", want: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{in: "202610171230", want: time.Date(2026, 10, 17, 12, 30, 0, 0, time.UTC)},
+		{in: "20261017123045", want: time.Date(2026, 10, 17, 12, 30, 45, 0, time.UTC)},
+		{in: "20261017123045.000", want: time.Date(2026, 10, 17, 12, 30, 45, 0, time.UTC)},
+		{in: "20261017120000.000[-5:EST]", want: time.Date(2026, 10, 17, 17, 0, 0, 0, time.UTC)},
+		{in: "20261017120000[+5.5:IST]", want: time.Date(2026, 10, 17, 6, 30, 0, 0, time.UTC)},
+		{in: "20261017000000[0]", want: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{in: "2026101", wantErr: true},
+		{in: "20261340", wantErr: true},
+		{in: "20261017[EST]", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseOFXDate(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseOFXDate(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseOFXDate(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseOFXSGML(t *testing.T) {
+	statements, err := Parse(readFixture(t, "sgml.ofx"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(statements) != 1 {
+		t.Fatalf("got %d statements, want 1", len(statements))
+	}
+	s := statements[0]
+	if s.Format != FormatOFX || s.Account != "000123456" || s.Currency != "USD" {
+		t.Errorf("statement = %s %q %q", s.Format, s.Account, s.Currency)
+	}
+	if len(s.Transactions) != 3 {
+		t.Fatalf("got %d transactions, want 3", len(s.Transactions))
+	}
+
+	grocery := s.Transactions[0]
+	want := Transaction{
+		FITID:    "2026100301",
+		PostedAt: time.Date(2026, 10, 3, 17, 0, 0, 0, time.UTC),
+		Amount:   -42.5,
+		Currency: "USD",
+		Payee:    "CORNER GROCERY & DELI",
+		Memo:     "POS PURCHASE",
+	}
+	if grocery != want {
+		t.Errorf("transaction 0 = %+v, want %+v", grocery, want)
+	}
+	if payroll := s.Transactions[1]; payroll.Amount != 1500 || payroll.FITID != "2026100501" {
+		t.Errorf("transaction 1 = %+v", payroll)
+	}
+	streaming := s.Transactions[2]
+	if streaming.Amount != -9.99 || !strings.HasPrefix(streaming.FITID, "gen-") {
+		t.Errorf("transaction 2 = %+v, want -9.99 with a generated id", streaming)
+	}
+}
+
+func TestParseOFXXML(t *testing.T) {
+	statements, err := Parse(readFixture(t, "xml.qfx"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(statements) != 1 {
+		t.Fatalf("got %d statements, want 1", len(statements))
+	}
+	s := statements[0]
+	if s.Account != "4111XXXXXXXX1111" || s.Currency != "EUR" {
+		t.Errorf("statement = %q %q", s.Account, s.Currency)
+	}
+	want := []Transaction{
+		{
+			FITID:    "CC-001",
+			PostedAt: time.Date(2026, 10, 12, 8, 30, 0, 0, time.UTC),
+			Amount:   -1234.56,
+			Currency: "USD",
+			Payee:    "Airline",
+		},
+		{
+			FITID:    "CC-002",
+			PostedAt: time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC),
+			Amount:   -3.2,
+			Currency: "EUR",
+			Payee:    "Coffee",
+		},
+	}
+	if len(s.Transactions) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(s.Transactions), len(want))
+	}
+	for i := range want {
+		if s.Transactions[i] != want[i] {
+			t.Errorf("transaction %d = %+v, want %+v", i, s.Transactions[i], want[i])
+		}
+	}
+}
+
+func TestParseOFXRejectsAmbiguousAmount(t *testing.T) {
+	data := "OFXHEADER:100\n<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>USD" +
+		"<BANKACCTFROM><ACCTID>1</BANKACCTFROM><BANKTRANLIST>" +
+		"<STMTTRN><DTPOSTED>20261001<TRNAMT>-1,234<FITID>A</STMTTRN>" +
+		"</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>"
+	if _, err := Parse([]byte(data)); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Parse error = %v, want an ambiguous amount", err)
+	}
+}
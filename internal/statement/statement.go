@@ -0,0 +1,141 @@
+// Package statement parses bank statement exports into transactions.
+//
+// Supported formats are OFX 1.x (SGML) and 2.x (XML), QFX, which is OFX with
+// Intuit additions, and ISO 20022 CAMT.053 bank-to-customer statements.
+package statement
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats Parse recognizes.
+const (
+	FormatOFX     = "ofx"
+	FormatCAMT053 = "camt.053"
+)
+
+var ErrUnknownFormat = errors.New("not an OFX, QFX or CAMT.053 statement")
+
+// Transaction is one booked statement line. Amount is negative for debits.
+type Transaction struct {
+	// FITID is the bank's id for the transaction, unique within the account.
+	// When the file has none a stable one is derived from the line itself.
+	FITID    string
+	PostedAt time.Time
+	Amount   float64
+	Currency string
+	Payee    string
+	Memo     string
+}
+
+// Statement is what a file says about one account.
+type Statement struct {
+	Format       string
+	Account      string
+	Currency     string
+	Transactions []Transaction
+}
+
+// Parse detects the format of data and parses it. Files holding several
+// accounts are returned as one statement per account.
+func Parse(data []byte) ([]*Statement, error) {
+	head := data[:min(len(data), 4096)]
+	switch {
+	case bytes.Contains(head, []byte("camt.053")) || bytes.Contains(head, []byte("<BkToCstmrStmt")):
+		return parseCAMT053(data)
+	case bytes.Contains(head, []byte("OFXHEADER")) || bytes.Contains(bytes.ToUpper(head), []byte("<OFX>")):
+		return parseOFX(data)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// parseAmount reads an OFX amount such as "1234.56", "-12,50", "1,234.56" or
+// "1.234,56". The last ',' or '.' is the decimal separator and the other may
+// group thousands before it; a separator used more than once only groups.
+// A lone separator followed by exactly three digits, as in "1,234", could be
+// either and is rejected rather than guessed.
+func parseAmount(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	number := strings.TrimLeft(s, "+-")
+	sign := s[:len(s)-len(number)]
+	whole, fraction := number, ""
+	if i := strings.LastIndexAny(number, ",."); i >= 0 && strings.Count(number, number[i:i+1]) == 1 {
+		whole, fraction = number[:i], number[i+1:]
+		if len(fraction) == 3 && len(whole) <= 3 && whole != "" && whole[0] != '0' && allDigits(whole) {
+			return 0, fmt.Errorf("ambiguous amount %q", s)
+		}
+		if fraction == "" {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+	whole, ok := ungroup(whole)
+	if !ok || len(sign) > 1 || !allDigits(fraction) || whole+fraction == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return strconv.ParseFloat(sign+whole+"."+fraction+"0", 64)
+}
+
+// ungroup removes thousands separators from the integer part of an amount:
+// one kind of separator, a leading group of one to three digits and then
+// groups of exactly three.
+func ungroup(whole string) (string, bool) {
+	i := strings.IndexAny(whole, ",.")
+	if i < 0 {
+		return whole, allDigits(whole)
+	}
+	groups := strings.Split(whole, whole[i:i+1])
+	if len(groups[0]) == 0 || len(groups[0]) > 3 {
+		return "", false
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return "", false
+		}
+	}
+	digits := strings.Join(groups, "")
+	return digits, allDigits(digits)
+}
+
+// parseDecimal reads an ISO 20022 amount: digits with an optional '.' before
+// the fraction, no grouping.
+func parseDecimal(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" || !allDigits(whole) || !allDigits(fraction) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// assignMissingIDs gives transactions without a bank id one derived from the
+// account, date, amount and payee, numbered among identical lines so that
+// re-importing the same file yields the same ids.
+func assignMissingIDs(account string, txns []Transaction) {
+	seen := map[string]int{}
+	for i := range txns {
+		t := &txns[i]
+		if t.FITID != "" {
+			continue
+		}
+		key := fmt.Sprintf("%s|%s|%.2f|%s", account, t.PostedAt.Format("2006-01-02"), t.Amount, t.Payee)
+		seen[key]++
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", key, seen[key])))
+		t.FITID = "gen-" + hex.EncodeToString(sum[:12])
+	}
+}
@@ -0,0 +1,125 @@
+package statement
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "1234.56", want: 1234.56},
+		{in: "-12,50", want: -12.5},
+		{in: "+7", want: 7},
+		{in: " -42.50 ", want: -42.5},
+		{in: "1,234.56", want: 1234.56},
+		{in: "1.234,56", want: 1234.56},
+		{in: "-1.234.567,89", want: -1234567.89},
+		{in: "1,234,567", want: 1234567},
+		{in: "1.234.567", want: 1234567},
+		{in: "0,125", want: 0.125},
+		{in: "1234,567", want: 1234.567},
+		{in: ".5", want: 0.5},
+		{in: "1,234", wantErr: true},
+		{in: "-1.234", wantErr: true},
+		{in: "1,234,56", wantErr: true},
+		{in: "12,34.56", wantErr: true},
+		{in: "1,234.567,89", wantErr: true},
+		{in: "12.", wantErr: true},
+		{in: "--5", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "NaN", wantErr: true},
+		{in: "1e3", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseAmount(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseAmount(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "150.00", want: 150},
+		{in: "12.345", want: 12.345},
+		{in: "7", want: 7},
+		{in: "1,50", wantErr: true},
+		{in: "-1.00", wantErr: true},
+		{in: ".5", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDecimal(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseDecimal(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseDecimal(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	for _, data := range []string{"", "date,amount\n2026-10-01,12.00\n", "<html><body>statement</body></html>"} {
+		if _, err := Parse([]byte(data)); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("Parse(%q) error = %v, want ErrUnknownFormat", data, err)
+		}
+	}
+}
+
+func TestAssignMissingIDsIsStable(t *testing.T) {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	lines := func() []Transaction {
+		return []Transaction{
+			{PostedAt: day, Amount: -5, Payee: "Kiosk"},
+			{PostedAt: day, Amount: -5, Payee: "Kiosk"},
+			{FITID: "bank-1", PostedAt: day, Amount: -5, Payee: "Kiosk"},
+		}
+	}
+	first, second := lines(), lines()
+	assignMissingIDs("acct", first)
+	assignMissingIDs("acct", second)
+
+	if first[0].FITID == first[1].FITID {
+		t.Errorf("identical lines share id %q", first[0].FITID)
+	}
+	for i := range first {
+		if first[i].FITID != second[i].FITID {
+			t.Errorf("line %d: id %q, then %q on re-import", i, first[i].FITID, second[i].FITID)
+		}
+	}
+	if !strings.HasPrefix(first[0].FITID, "gen-") || first[2].FITID != "bank-1" {
+		t.Errorf("ids = %q, %q, %q", first[0].FITID, first[1].FITID, first[2].FITID)
+	}
+}
@@ -0,0 +1,174 @@
+// Package stream pushes a user's expense events to their open connections
+// as they commit. Postgres LISTEN/NOTIFY carries the wake-ups, so a change
+// made through any instance reaches streams on every instance; the events
+// themselves are always read from the outbox table.
+package stream
+
+import (
+	"context"
+	"expense-tracker/internal/apperr"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Channel is the notification channel the outbox trigger publishes on.
+const Channel = "outbox_events"
+
+var ErrTooManyStreams = apperr.New(apperr.KindTooManyRequests, "too many open streams, close one first")
+
+// Broker holds one listening connection and wakes the subscriptions of the
+// user each notification names.
+type Broker struct {
+	pool    *pgxpool.Pool
+	maxSubs int
+
+	mu   sync.Mutex
+	subs map[int]map[*Subscription]struct{}
+	done chan struct{}
+}
+
+// NewBroker returns a broker allowing maxPerUser subscriptions per user.
+func NewBroker(pool *pgxpool.Pool, maxPerUser int) *Broker {
+	return &Broker{
+		pool:    pool,
+		maxSubs: maxPerUser,
+		subs:    map[int]map[*Subscription]struct{}{},
+		done:    make(chan struct{}),
+	}
+}
+
+// Subscription receives a wake-up whenever its user may have new events.
+// Wake-ups coalesce: a stream that is busy sending sees one, not many.
+type Subscription struct {
+	C <-chan struct{}
+
+	c      chan struct{}
+	userID int
+	broker *Broker
+}
+
+// Subscribe registers a subscription for userID. Close it when done.
+func (b *Broker) Subscribe(userID int) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.subs[userID]) >= b.maxSubs {
+		return nil, ErrTooManyStreams
+	}
+	c := make(chan struct{}, 1)
+	sub := &Subscription{C: c, c: c, userID: userID, broker: b}
+	if b.subs[userID] == nil {
+		b.subs[userID] = map[*Subscription]struct{}{}
+	}
+	b.subs[userID][sub] = struct{}{}
+	return sub, nil
+}
+
+func (s *Subscription) Close() {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subs[s.userID], s)
+	if len(b.subs[s.userID]) == 0 {
+		delete(b.subs, s.userID)
+	}
+}
+
+// Done is closed once the broker has stopped; streams should end then.
+func (b *Broker) Done() <-chan struct{} {
+	return b.done
+}
+
+// Run listens until ctx is cancelled, reconnecting with backoff when the
+// connection drops. Every subscription is woken after a reconnect since
+// notifications sent in between are lost.
+func (b *Broker) Run(ctx context.Context) {
+	defer close(b.done)
+
+	backoff := time.Second
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			slog.Info("stream broker stopped")
+			return
+		}
+		slog.Error("stream broker lost its connection", "error", err, "retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("stream broker stopped")
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+func (b *Broker) listen(ctx context.Context) error {
+	pooled, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// a listening connection must not go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	b.wakeAll()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		userID, ok := parsePayload(n.Payload)
+		if !ok {
+			slog.Warn("ignoring malformed stream notification", "payload", n.Payload)
+			continue
+		}
+		b.wake(userID)
+	}
+}
+
+// parsePayload reads the user id of a "<user_id>:<event_id>" payload.
+func parsePayload(payload string) (int, bool) {
+	user, _, ok := strings.Cut(payload, ":")
+	if !ok {
+		return 0, false
+	}
+	userID, err := strconv.Atoi(user)
+	return userID, err == nil
+}
+
+func (b *Broker) wake(userID int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs[userID] {
+		sub.notify()
+	}
+}
+
+func (b *Broker) wakeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, subs := range b.subs {
+		for sub := range subs {
+			sub.notify()
+		}
+	}
+}
+
+func (s *Subscription) notify() {
+	select {
+	case s.c <- struct{}{}:
+	default:
+	}
+}
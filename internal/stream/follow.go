@@ -0,0 +1,67 @@
+package stream
+
+import (
+	"context"
+	"expense-tracker/internal/model"
+	"time"
+)
+
+const fetchLimit = 100
+
+// ResetEvent tells a client that events it missed are gone and it should
+// reload what it shows before following the stream again.
+const ResetEvent = "stream.reset"
+
+// Fetch returns up to limit events with a seq above afterSeq, in seq order.
+type Fetch func(ctx context.Context, afterSeq int64, limit int) ([]*model.OutboxEvent, error)
+
+// Sink is one transport's way of writing to the client.
+type Sink interface {
+	Event(event *model.OutboxEvent) error
+	// Reset sends a ResetEvent; the client resumes after seq.
+	Reset(seq int64) error
+	Heartbeat() error
+}
+
+// Follow sends every event after seq afterSeq to sink, then keeps sending
+// new ones as sub is woken, with a heartbeat whenever the stream has been
+// quiet for heartbeat. It returns when ctx ends, the broker stops or a write
+// fails.
+//
+// A user's events commit in seq order, so once an event is read every one
+// before it has been too and nothing is skipped.
+func Follow(ctx context.Context, broker *Broker, sub *Subscription, afterSeq int64, heartbeat time.Duration, fetch Fetch, sink Sink) error {
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		for {
+			events, err := fetch(ctx, afterSeq, fetchLimit)
+			if err != nil {
+				return err
+			}
+			for _, event := range events {
+				if err := sink.Event(event); err != nil {
+					return err
+				}
+				afterSeq = event.Seq
+			}
+			if len(events) < fetchLimit {
+				break
+			}
+		}
+		ticker.Reset(heartbeat)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-broker.Done():
+			return nil
+		case <-sub.C:
+		case <-ticker.C:
+			if err := sink.Heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer is a pgx.QueryTracer that opens a client span per query.
+// Arguments are never recorded, only the SQL text.
+type QueryTracer struct{}
+
+var _ pgx.QueryTracer = QueryTracer{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		// don't start root traces for queries outside a request, e.g. background jobs
+		return ctx
+	}
+	sql := strings.TrimSpace(data.SQL)
+	ctx, _ = Start(ctx, "db "+operation(sql),
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBQueryText(sql),
+	)
+	return ctx
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	End(span, data.Err)
+}
+
+// operation is the first SQL keyword, used to keep span names low-cardinality.
+func operation(sql string) string {
+	op, _, _ := strings.Cut(sql, " ")
+	op = strings.ToUpper(strings.TrimSpace(op))
+	if op == "" {
+		return "QUERY"
+	}
+	return op
+}
@@ -1,42 +1,83 @@
 package utils
 
 import (
-	"log"
-	"os"
+	"errors"
+	"expense-tracker/internal/apperr"
+	"expense-tracker/internal/config"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GetSecretKey() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		log.Fatal("JWT_SECRET not set in environment")
+// TokenManager issues and validates the API's HS256 access tokens.
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
+	issuer string
+}
+
+func NewTokenManager(cfg config.JWTConfig) *TokenManager {
+	return &TokenManager{
+		secret: []byte(cfg.Secret),
+		ttl:    cfg.TTL,
+		issuer: cfg.Issuer,
 	}
-	return []byte(secret)
 }
 
-func GenerateToken(userID int64) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+func (m *TokenManager) GenerateToken(userID int64) (string, error) {
+	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
-	})
-	return token.SignedString(GetSecretKey())
+		"exp":     time.Now().Add(m.ttl).Unix(),
+	}
+	if m.issuer != "" {
+		claims["iss"] = m.issuer
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(m.secret)
 }
 
 // middleware
-func ValidateToken(tokenStr string) (*jwt.Token, error) {
+func (m *TokenManager) ValidateToken(tokenStr string) (*jwt.Token, error) {
+	opts := []jwt.ParserOption{jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+
 	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		return GetSecretKey(), nil
-	})
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return m.secret, nil
+	}, opts...)
 
 	if err != nil {
 		return nil, err // e.g., signature invalid, expired, etc.
 	}
 
 	if !token.Valid {
-		return nil, err
+		return nil, errors.New("invalid token")
 	}
 
 	return token, nil
 }
+
+// Authenticate validates a bearer token and returns the user it was issued
+// to. Failures are apperr.Unauthorized errors safe to show to the caller.
+func (m *TokenManager) Authenticate(tokenStr string) (int, error) {
+	token, err := m.ValidateToken(tokenStr)
+	if err != nil || !token.Valid {
+		return 0, apperr.Unauthorized("invalid or expired token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, apperr.Unauthorized("invalid token claims")
+	}
+
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, apperr.Unauthorized("user_id missing")
+	}
+	return int(userID), nil
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	"expense-tracker/internal/repository"
+	"log/slog"
+	"time"
+)
+
+// IdempotencyCleaner periodically deletes expired idempotency records so the
+// table only holds keys that can still be replayed.
+type IdempotencyCleaner struct {
+	store    repository.IdempotencyRepository
+	interval time.Duration
+}
+
+func NewIdempotencyCleaner(store repository.IdempotencyRepository, interval time.Duration) *IdempotencyCleaner {
+	return &IdempotencyCleaner{store: store, interval: interval}
+}
+
+// Run cleans once immediately and then on every tick until ctx is cancelled.
+func (w *IdempotencyCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.clean(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("idempotency cleaner stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *IdempotencyCleaner) clean(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	deleted, err := w.store.DeleteExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		slog.Error("failed to delete expired idempotency keys", "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.Info("deleted expired idempotency keys", "count", deleted)
+	}
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"expense-tracker/internal/services"
+	"log/slog"
+	"time"
+)
+
+// OutboxPurger periodically deletes outbox events past their retention. It
+// runs whether or not webhooks are on, since every change writes an event;
+// with webhooks off nothing dispatches them, so undispatched events expire
+// by age instead.
+type OutboxPurger struct {
+	webhookService *services.WebhookService
+	retention      time.Duration
+	interval       time.Duration
+	undispatched   bool
+}
+
+func NewOutboxPurger(webhookService *services.WebhookService, retention, interval time.Duration, undispatched bool) *OutboxPurger {
+	return &OutboxPurger{webhookService: webhookService, retention: retention, interval: interval, undispatched: undispatched}
+}
+
+// Run purges once immediately and then on every tick until ctx is cancelled.
+func (w *OutboxPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("outbox purger stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *OutboxPurger) purge(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	purged, err := w.webhookService.PurgeEventsService(ctx, w.retention, w.undispatched)
+	if err != nil {
+		slog.Error("failed to purge outbox events", "error", err)
+		return
+	}
+	if purged > 0 {
+		slog.Info("purged outbox events", "count", purged)
+	}
+}
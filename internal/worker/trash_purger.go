@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"expense-tracker/internal/services"
+	"log/slog"
+	"time"
+)
+
+// TrashPurger periodically removes expenses that have outlived the trash retention window.
+type TrashPurger struct {
+	expenseService *services.ExpenseService
+	retention      time.Duration
+	interval       time.Duration
+}
+
+func NewTrashPurger(expenseService *services.ExpenseService, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		expenseService: expenseService,
+		retention:      retention,
+		interval:       interval,
+	}
+}
+
+// Run purges once immediately and then on every tick until ctx is cancelled.
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("trash purger stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	purged, err := p.expenseService.PurgeTrashService(ctx, p.retention)
+	if err != nil {
+		slog.Error("failed to purge trash", "error", err)
+		return
+	}
+	if purged > 0 {
+		slog.Info("purged expired expenses from trash", "count", purged)
+	}
+}
@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"context"
+	"expense-tracker/internal/metrics"
+	"expense-tracker/internal/model"
+	"expense-tracker/internal/services"
+	"expense-tracker/internal/webhook"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const webhookConcurrency = 10
+
+// WebhookDispatcher moves outbox events into webhook deliveries and sends the
+// deliveries that are due, retrying failures with backoff.
+type WebhookDispatcher struct {
+	webhookService *services.WebhookService
+	sender         *webhook.Sender
+	interval       time.Duration
+	timeout        time.Duration
+	batchSize      int
+}
+
+func NewWebhookDispatcher(webhookService *services.WebhookService, sender *webhook.Sender, interval, timeout time.Duration, batchSize int) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhookService: webhookService,
+		sender:         sender,
+		interval:       interval,
+		timeout:        timeout,
+		batchSize:      batchSize,
+	}
+}
+
+// Run polls once immediately and then on every tick until ctx is cancelled.
+// Several instances may run side by side: claims skip rows another instance
+// has locked or leased.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			slog.Info("webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *WebhookDispatcher) poll(ctx context.Context) {
+	d.dispatch(ctx)
+	d.deliver(ctx)
+}
+
+func (d *WebhookDispatcher) dispatch(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	dispatched, err := d.webhookService.DispatchEventsService(ctx, d.batchSize)
+	if err != nil {
+		slog.Error("failed to dispatch webhook events", "error", err)
+		return
+	}
+	if dispatched > 0 {
+		slog.Debug("dispatched webhook events", "count", dispatched)
+	}
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context) {
+	// the lease outlasts a full attempt so a slow endpoint is not sent the
+	// same delivery twice
+	deliveries, err := d.webhookService.ClaimDeliveriesService(ctx, d.batchSize, 2*d.timeout+time.Minute)
+	if err != nil {
+		slog.Error("failed to claim webhook deliveries", "error", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, webhookConcurrency)
+	for _, delivery := range deliveries {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			d.send(ctx, delivery)
+		})
+	}
+	wg.Wait()
+}
+
+func (d *WebhookDispatcher) send(ctx context.Context, delivery *model.PendingDelivery) {
+	sendCtx, cancel := context.WithTimeout(ctx, d.timeout)
+	statusCode, sendErr := d.sender.Send(sendCtx, delivery)
+	cancel()
+
+	// record the outcome even when shutdown interrupted the attempt
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	result, err := d.webhookService.RecordDeliveryService(recordCtx, delivery, statusCode, sendErr)
+	if err != nil {
+		slog.Error("failed to record webhook delivery", "delivery_id", delivery.ID, "error", err)
+		return
+	}
+	metrics.WebhookDeliveries.WithLabelValues(result).Inc()
+	if sendErr != nil {
+		slog.Warn("webhook delivery failed", "delivery_id", delivery.ID, "event_type", delivery.Event.Type,
+			"attempt", delivery.Attempts, "result", result, "error", sendErr)
+	}
+}
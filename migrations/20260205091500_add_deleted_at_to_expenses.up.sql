@@ -0,0 +1,3 @@
+ALTER TABLE expenses ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_expenses_deleted_at ON expenses (deleted_at) WHERE deleted_at IS NOT NULL;
\ No newline at end of file
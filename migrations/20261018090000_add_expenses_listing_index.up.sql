@@ -0,0 +1,2 @@
+-- Keyset pagination over a user's live expenses, newest first.
+CREATE INDEX idx_expenses_user_created ON expenses (user_id, created_at DESC, id DESC) WHERE deleted_at IS NULL;
@@ -0,0 +1,9 @@
+-- A monthly spending limit per category; crossing it emits budget.exceeded.
+CREATE TABLE budgets (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category TEXT NOT NULL,
+    monthly_limit NUMERIC(10, 2) NOT NULL CHECK (monthly_limit > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, category)
+);
@@ -0,0 +1,15 @@
+-- Wake the expense streams of every instance when an event commits. The
+-- payload is "<user_id>:<event_id>"; listeners read the event itself from
+-- the table.
+CREATE FUNCTION notify_outbox_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('outbox_events', NEW.user_id || ':' || NEW.id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER outbox_events_notify
+    AFTER INSERT ON outbox_events
+    FOR EACH ROW EXECUTE FUNCTION notify_outbox_event();
+
+CREATE INDEX idx_outbox_events_user ON outbox_events (user_id, id);
@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS expenses_record_tombstone ON expenses;
+DROP TRIGGER IF EXISTS expenses_track_change ON expenses;
+DROP FUNCTION IF EXISTS expenses_record_tombstone();
+DROP FUNCTION IF EXISTS expenses_track_change();
+DROP FUNCTION IF EXISTS next_expense_change(INTEGER);
+DROP TABLE IF EXISTS expense_tombstones;
+DROP INDEX IF EXISTS idx_expenses_user_change_seq;
+DROP INDEX IF EXISTS idx_expenses_user_uuid;
+ALTER TABLE expenses
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS change_seq,
+    DROP COLUMN IF EXISTS uuid;
+DROP SEQUENCE IF EXISTS expense_change_seq;
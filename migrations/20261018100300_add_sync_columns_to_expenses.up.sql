@@ -0,0 +1,57 @@
+-- Offline sync. Every expense gets a stable uuid clients can create it
+-- under, and every change to it the next value of expense_change_seq, so a
+-- client that has seen changes up to n only needs those above n. Expenses
+-- purged from the trash leave a tombstone carrying the same sequence.
+CREATE SEQUENCE expense_change_seq;
+
+ALTER TABLE expenses
+    ADD COLUMN uuid UUID NOT NULL DEFAULT gen_random_uuid(),
+    ADD COLUMN change_seq BIGINT NOT NULL DEFAULT nextval('expense_change_seq'),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE UNIQUE INDEX idx_expenses_user_uuid ON expenses (user_id, uuid);
+CREATE INDEX idx_expenses_user_change_seq ON expenses (user_id, change_seq);
+
+-- No foreign key: rows are written while a user's expenses are cascade-deleted.
+CREATE TABLE expense_tombstones (
+    user_id INTEGER NOT NULL,
+    expense_id INTEGER NOT NULL,
+    uuid UUID NOT NULL,
+    change_seq BIGINT NOT NULL,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, change_seq)
+);
+
+-- Numbers one change of owner's. The lock is held until commit, so changes
+-- of one user commit in sequence order and a reader that sees n+1 has
+-- already seen n.
+CREATE FUNCTION next_expense_change(owner INTEGER) RETURNS BIGINT AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('expense_changes'), owner);
+    RETURN nextval('expense_change_seq');
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION expenses_track_change() RETURNS trigger AS $$
+BEGIN
+    NEW.change_seq := next_expense_change(NEW.user_id);
+    NEW.updated_at := NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER expenses_track_change
+    BEFORE INSERT OR UPDATE ON expenses
+    FOR EACH ROW EXECUTE FUNCTION expenses_track_change();
+
+CREATE FUNCTION expenses_record_tombstone() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO expense_tombstones (user_id, expense_id, uuid, change_seq)
+    VALUES (OLD.user_id, OLD.id, OLD.uuid, next_expense_change(OLD.user_id));
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER expenses_record_tombstone
+    AFTER DELETE ON expenses
+    FOR EACH ROW EXECUTE FUNCTION expenses_record_tombstone();
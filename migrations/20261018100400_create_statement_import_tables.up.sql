@@ -0,0 +1,33 @@
+-- An uploaded bank statement. Its debits become candidates the user reviews
+-- before any of them turns into an expense.
+CREATE TABLE statement_imports (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    format TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_statement_imports_user ON statement_imports (user_id, id);
+
+-- fitid is the bank's transaction id, unique per account, so uploading
+-- overlapping statements never yields the same transaction twice.
+CREATE TABLE import_candidates (
+    id BIGSERIAL PRIMARY KEY,
+    import_id INTEGER NOT NULL REFERENCES statement_imports(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    account TEXT NOT NULL,
+    fitid TEXT NOT NULL,
+    posted_at TIMESTAMP NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+    currency TEXT NOT NULL DEFAULT '',
+    payee TEXT NOT NULL DEFAULT '',
+    memo TEXT NOT NULL DEFAULT '',
+    category TEXT,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'discarded')),
+    expense_id INTEGER REFERENCES expenses(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    UNIQUE (user_id, account, fitid)
+);
+
+CREATE INDEX idx_import_candidates_import ON import_candidates (import_id, id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS categorization_rules;
+DROP INDEX IF EXISTS idx_expenses_user_description;
+ALTER TABLE expenses
+    DROP COLUMN IF EXISTS reimbursable,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS description;
@@ -0,0 +1,30 @@
+ALTER TABLE expenses
+    ADD COLUMN description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN reimbursable BOOLEAN NOT NULL DEFAULT false;
+
+-- Learned suggestions look up the categories a description was filed under
+CREATE INDEX idx_expenses_user_description ON expenses (user_id, lower(description))
+    WHERE description <> '' AND deleted_at IS NULL;
+
+-- A rule matches when every condition that is set holds, and then applies
+-- every action that is set. Rules run in ascending priority.
+CREATE TABLE categorization_rules (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 100,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    description_contains TEXT,
+    description_regex TEXT,
+    min_amount NUMERIC(10, 2),
+    max_amount NUMERIC(10, 2),
+    weekdays TEXT[] NOT NULL DEFAULT '{}',
+    set_category TEXT,
+    add_tags TEXT[] NOT NULL DEFAULT '{}',
+    mark_reimbursable BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_categorization_rules_user ON categorization_rules (user_id, priority, id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS outbox_purges;
+DROP INDEX IF EXISTS idx_outbox_events_user_seq;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_user ON outbox_events (user_id, id);
+DROP TRIGGER IF EXISTS outbox_events_sequence ON outbox_events;
+DROP FUNCTION IF EXISTS outbox_events_sequence();
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS seq;
@@ -0,0 +1,39 @@
+-- Expense streams resume by position in the user's event log. Event ids are
+-- handed out before commit, so two transactions of one user can commit out
+-- of id order; seq is drawn from next_expense_change instead, whose lock
+-- makes a user's events commit in seq order. Existing events keep their id
+-- as seq, so positions clients already hold stay valid.
+ALTER TABLE outbox_events ADD COLUMN seq BIGINT;
+UPDATE outbox_events SET seq = id;
+ALTER TABLE outbox_events ALTER COLUMN seq SET NOT NULL;
+
+SELECT setval('expense_change_seq', GREATEST(
+    (SELECT last_value FROM expense_change_seq),
+    (SELECT COALESCE(MAX(id), 1) FROM outbox_events)
+));
+
+CREATE FUNCTION outbox_events_sequence() RETURNS trigger AS $$
+BEGIN
+    NEW.seq := next_expense_change(NEW.user_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER outbox_events_sequence
+    BEFORE INSERT ON outbox_events
+    FOR EACH ROW EXECUTE FUNCTION outbox_events_sequence();
+
+DROP INDEX IF EXISTS idx_outbox_events_user;
+CREATE INDEX idx_outbox_events_user_seq ON outbox_events (user_id, seq);
+
+-- The highest seq purged per user. A stream resuming below it has missed
+-- events that are gone and must start over.
+CREATE TABLE outbox_purges (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    through_seq BIGINT NOT NULL
+);
+
+-- A user's ids below their oldest remaining event can only belong to events
+-- already purged.
+INSERT INTO outbox_purges (user_id, through_seq)
+SELECT user_id, MIN(seq) - 1 FROM outbox_events GROUP BY user_id;
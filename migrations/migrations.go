@@ -0,0 +1,11 @@
+// Package migrations embeds the SQL schema migrations into the binary.
+//
+// Files are named <version>_<description>.up.sql and .down.sql, where version
+// is a UTC timestamp (YYYYMMDDhhmmss). Every up migration needs a matching
+// down migration that fully reverts it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,10 @@
+#!/bin/bash
+set -e
+
+# Go to project root
+cd "$(dirname "$0")/.."
+
+# Regenerates internal/gen from proto/. Needs buf, protoc-gen-go and
+# protoc-gen-go-grpc on PATH.
+buf lint
+buf generate